@@ -3,6 +3,8 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 )
 
 // ActiveConnection represents a currently-active remote desktop session.
@@ -25,6 +27,65 @@ func (c *Client) ListActiveConnections(ctx context.Context) (map[string]ActiveCo
 	return result, nil
 }
 
+// ListActiveConnectionsByUser returns the active sessions opened by
+// username, keyed by active-connection identifier. Guacamole offers no
+// server-side filter, so the full list is fetched and filtered locally. The
+// map is empty, never nil, when nothing matches.
+func (c *Client) ListActiveConnectionsByUser(ctx context.Context, username string) (map[string]ActiveConnection, error) {
+	return c.filterActiveConnections(ctx, func(ac ActiveConnection) bool {
+		return ac.Username == username
+	})
+}
+
+// ListActiveConnectionsByConnection returns the active sessions on the
+// connection identified by connectionID, filtered locally like
+// ListActiveConnectionsByUser.
+func (c *Client) ListActiveConnectionsByConnection(ctx context.Context, connectionID string) (map[string]ActiveConnection, error) {
+	return c.filterActiveConnections(ctx, func(ac ActiveConnection) bool {
+		return ac.ConnectionIdentifier == connectionID
+	})
+}
+
+// GetUserActiveSessions returns username's active sessions ordered by start
+// time, oldest first (ties broken by identifier), e.g. to end them all with
+// KillActiveConnection. The slice is empty when the user has no sessions.
+func (c *Client) GetUserActiveSessions(ctx context.Context, username string) ([]ActiveConnection, error) {
+	active, err := c.ListActiveConnectionsByUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]ActiveConnection, 0, len(active))
+	for id, ac := range active {
+		if ac.Identifier == "" {
+			ac.Identifier = id
+		}
+		sessions = append(sessions, ac)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].StartDate != sessions[j].StartDate {
+			return sessions[i].StartDate < sessions[j].StartDate
+		}
+		return sessions[i].Identifier < sessions[j].Identifier
+	})
+	return sessions, nil
+}
+
+// filterActiveConnections returns the active sessions for which keep
+// reports true.
+func (c *Client) filterActiveConnections(ctx context.Context, keep func(ActiveConnection) bool) (map[string]ActiveConnection, error) {
+	active, err := c.ListActiveConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]ActiveConnection)
+	for id, ac := range active {
+		if keep(ac) {
+			result[id] = ac
+		}
+	}
+	return result, nil
+}
+
 // KillActiveConnection forcibly terminates the active session with the given
 // identifier.
 func (c *Client) KillActiveConnection(ctx context.Context, id string) error {
@@ -33,3 +94,43 @@ func (c *Client) KillActiveConnection(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// IsConnectionActive reports whether anyone currently has a session open on
+// the connection identified by connectionID. It consults the active
+// connections list, which is current at the time of the call, rather than the
+// connection's ActiveConnections counter.
+func (c *Client) IsConnectionActive(ctx context.Context, connectionID string) (bool, error) {
+	active, err := c.ListActiveConnections(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, ac := range active {
+		if ac.ConnectionIdentifier == connectionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WaitConnectionInactive blocks until nobody has a session open on the
+// connection identified by connectionID, checking with IsConnectionActive
+// every poll interval (one second if poll is not positive). Bound the wait with
+// a context deadline; when ctx ends first its error is returned. Errors from
+// IsConnectionActive end the wait immediately.
+func (c *Client) WaitConnectionInactive(ctx context.Context, connectionID string, poll time.Duration) error {
+	if poll <= 0 {
+		poll = time.Second
+	}
+	for {
+		active, err := c.IsConnectionActive(ctx, connectionID)
+		if err != nil {
+			return fmt.Errorf("guacamole: wait for connection %s to become inactive: %w", connectionID, err)
+		}
+		if !active {
+			return nil
+		}
+		if err := c.sleep(ctx, poll); err != nil {
+			return fmt.Errorf("guacamole: wait for connection %s to become inactive: %w", connectionID, err)
+		}
+	}
+}
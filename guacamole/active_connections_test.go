@@ -0,0 +1,161 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsConnectionActive(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/activeConnections")
+		writeJSON(t, w, map[string]ActiveConnection{
+			"a1b2": {Identifier: "a1b2", ConnectionIdentifier: "42", Username: "alice", Active: true},
+		})
+	})
+	cases := []struct {
+		id   string
+		want bool
+	}{
+		{"42", true},
+		{"7", false},
+	}
+	for _, tc := range cases {
+		got, err := c.IsConnectionActive(context.Background(), tc.id)
+		if err != nil {
+			t.Fatalf("IsConnectionActive(%s): %v", tc.id, err)
+		}
+		if got != tc.want {
+			t.Errorf("IsConnectionActive(%s): got %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
+
+// busyServer serves three sessions: alice on 42 twice and bob on 7.
+func busyServer(t *testing.T) *Client {
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/activeConnections")
+		writeJSON(t, w, map[string]ActiveConnection{
+			"s1": {Identifier: "s1", ConnectionIdentifier: "42", Username: "alice"},
+			"s2": {Identifier: "s2", ConnectionIdentifier: "42", Username: "alice"},
+			"s3": {Identifier: "s3", ConnectionIdentifier: "7", Username: "bob"},
+		})
+	})
+}
+
+func TestListActiveConnectionsByUser(t *testing.T) {
+	c := busyServer(t)
+	got, err := c.ListActiveConnectionsByUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("ListActiveConnectionsByUser: %v", err)
+	}
+	if len(got) != 2 || got["s1"].Username != "alice" || got["s2"].Username != "alice" {
+		t.Errorf("sessions: got %+v, want s1 and s2", got)
+	}
+
+	got, err = c.ListActiveConnectionsByUser(context.Background(), "carol")
+	if err != nil {
+		t.Fatalf("ListActiveConnectionsByUser: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("no match: got %#v, want empty non-nil map", got)
+	}
+}
+
+func TestListActiveConnectionsByConnection(t *testing.T) {
+	c := busyServer(t)
+	got, err := c.ListActiveConnectionsByConnection(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("ListActiveConnectionsByConnection: %v", err)
+	}
+	if len(got) != 1 || got["s3"].Username != "bob" {
+		t.Errorf("sessions: got %+v, want only s3", got)
+	}
+}
+
+func TestGetUserActiveSessions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/activeConnections")
+		writeJSON(t, w, map[string]ActiveConnection{
+			"late":  {ConnectionIdentifier: "1", Username: "alice", StartDate: 3000},
+			"early": {ConnectionIdentifier: "2", Username: "alice", StartDate: 1000},
+			"b":     {ConnectionIdentifier: "3", Username: "alice", StartDate: 2000},
+			"a":     {ConnectionIdentifier: "4", Username: "alice", StartDate: 2000},
+			"other": {ConnectionIdentifier: "1", Username: "bob", StartDate: 500},
+		})
+	})
+	got, err := c.GetUserActiveSessions(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("GetUserActiveSessions: %v", err)
+	}
+	var ids []string
+	for _, s := range got {
+		ids = append(ids, s.Identifier)
+	}
+	if strings.Join(ids, ",") != "early,a,b,late" {
+		t.Errorf("sessions: got %v, want [early a b late]", ids)
+	}
+
+	got, err = c.GetUserActiveSessions(context.Background(), "carol")
+	if err != nil || len(got) != 0 {
+		t.Errorf("carol: got %v, %v; want no sessions", got, err)
+	}
+}
+
+func TestWaitConnectionInactive(t *testing.T) {
+	var polls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/activeConnections")
+		sessions := map[string]ActiveConnection{}
+		// Active for the first two polls, then everyone has left.
+		if atomic.AddInt32(&polls, 1) <= 2 {
+			sessions["s1"] = ActiveConnection{Identifier: "s1", ConnectionIdentifier: "42"}
+		}
+		writeJSON(t, w, sessions)
+	})
+	fc := newFakeClock()
+	withClock(c, fc)
+
+	done := make(chan error, 1)
+	go func() { done <- c.WaitConnectionInactive(context.Background(), "42", 10*time.Second) }()
+	for i := 0; i < 2; i++ {
+		fc.waitForWaiter(t)
+		fc.Advance(10 * time.Second)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitConnectionInactive: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitConnectionInactive did not return once the connection was inactive")
+	}
+	if n := atomic.LoadInt32(&polls); n != 3 {
+		t.Errorf("polls: got %d, want 3", n)
+	}
+}
+
+func TestWaitConnectionInactive_context_ends_wait(t *testing.T) {
+	c := busyServer(t)
+	fc := newFakeClock()
+	withClock(c, fc)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- c.WaitConnectionInactive(ctx, "42", time.Minute) }()
+	fc.waitForWaiter(t)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err: got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitConnectionInactive ignored context cancellation")
+	}
+}
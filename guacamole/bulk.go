@@ -0,0 +1,70 @@
+package guacamole
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// bulkWorkers is the maximum number of requests bulk helpers keep in flight
+// at once.
+const bulkWorkers = 8
+
+// forEachBounded calls fn for every item using at most workers goroutines and
+// waits for all calls to finish. Items are not processed in any particular
+// order, so fn must be safe for concurrent use.
+func forEachBounded(items []string, workers int, fn func(item string)) {
+	forEachIndexBounded(len(items), workers, func(i int) { fn(items[i]) })
+}
+
+// forEachIndexBounded is forEachBounded over the indexes 0 to n-1, for
+// callers that fill result slices parallel to their input.
+func forEachIndexBounded(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// dedupe returns items with duplicates removed, preserving first occurrence
+// order.
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// joinErrors combines a map of per-item errors into one error, ordered by key
+// for deterministic messages. It returns nil for an empty map.
+func joinErrors(errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]error, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, errs[k])
+	}
+	return errors.Join(list...)
+}
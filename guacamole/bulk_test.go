@@ -0,0 +1,52 @@
+package guacamole
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachBounded_limits_concurrency(t *testing.T) {
+	var inFlight, peak int32
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	items := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	forEachBounded(items, 3, func(item string) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		mu.Lock()
+		seen[item] = true
+		mu.Unlock()
+	})
+
+	if peak > 3 {
+		t.Errorf("peak concurrency: got %d, want <= 3", peak)
+	}
+	if len(seen) != len(items) {
+		t.Errorf("processed: got %d items, want %d", len(seen), len(items))
+	}
+}
+
+func TestForEachIndexBounded_visits_each_index_once(t *testing.T) {
+	counts := make([]int32, 20)
+	forEachIndexBounded(len(counts), 4, func(i int) {
+		atomic.AddInt32(&counts[i], 1)
+	})
+	for i, n := range counts {
+		if n != 1 {
+			t.Errorf("index %d: visited %d times, want 1", i, n)
+		}
+	}
+	forEachIndexBounded(0, 4, func(i int) {
+		t.Errorf("visited index %d of an empty range", i)
+	})
+}
@@ -12,14 +12,17 @@ package guacamole
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -30,44 +33,113 @@ type Client struct {
 	httpClient *http.Client
 	authToken  string
 	dataSource string
+
+	// username is the authenticated user as reported by Authenticate.
+	username string
+
+	// availableDataSources lists every data source the current token may be
+	// used with, as reported by Authenticate.
+	availableDataSources []string
+
+	// timeouts holds per-category request deadlines; nil means only the
+	// http.Client timeout applies. See WithTimeouts.
+	timeouts *Timeouts
+
+	// metrics receives per-request observations; see WithMetrics.
+	metrics MetricsObserver
+
+	// requestIDHeader and requestIDFunc set a correlation header from the
+	// request context; see WithRequestIDHeader.
+	requestIDHeader string
+	requestIDFunc   func(context.Context) string
+
+	// allowAnonymous permits requests without an auth token; see
+	// WithAllowAnonymous.
+	allowAnonymous bool
+
+	// apiPrefix is the path of the REST API below baseURL; "" means the
+	// default "/api". See WithAPIPrefix.
+	apiPrefix string
+
+	// responseHook observes successful responses; see WithResponseHook.
+	responseHook func(*http.Response)
+
+	// treeMaxDepth bounds the nesting accepted from GetConnectionGroupTree;
+	// 0 means unlimited. See WithTreeMaxDepth.
+	treeMaxDepth int
+
+	// etags holds per-session, per-path ETags for conditional GETs; nil
+	// disables them. See WithConditionalRequests.
+	etags *etagCache
+
+	// clock is the time source for polling sleeps and request timing. A nil
+	// clock means the real time package; tests inject a fake via withClock.
+	clock clock
+}
+
+// normalizeBaseURL cleans the path of a user-supplied base URL so that
+// request paths can be appended directly: repeated slashes are collapsed and
+// any trailing slash is removed. Unparseable input is only trimmed.
+func normalizeBaseURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(raw, "/")
+	}
+	p := u.Path
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+	p = strings.TrimRight(p, "/")
+	if p != "" {
+		p = path.Clean(p)
+	}
+	u.Path = p
+	u.RawPath = ""
+	return strings.TrimRight(u.String(), "/")
 }
 
 // NewClient creates a new Client targeting the given Guacamole base URL (e.g.
 // "http://localhost:8080/guacamole"). The client uses a 30-second timeout by
-// default.
-func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL: strings.TrimRight(baseURL, "/"),
+// default; pass Options to customise it further.
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: normalizeBaseURL(baseURL),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
+	c.applyOptions(opts)
+	return c
 }
 
 // NewClientWithHTTPClient creates a new Client with a caller-supplied
 // *http.Client. This is useful for supplying custom TLS configuration or
 // transport-level logging.
-func NewClientWithHTTPClient(baseURL string, httpClient *http.Client) *Client {
-	return &Client{
-		baseURL:    strings.TrimRight(baseURL, "/"),
+func NewClientWithHTTPClient(baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    normalizeBaseURL(baseURL),
 		httpClient: httpClient,
 	}
+	c.applyOptions(opts)
+	return c
 }
 
 // NewClientWithToken creates a Client pre-loaded with an existing auth token
 // and data source, bypassing the Authenticate step. This is useful when the
 // caller already holds a Guacamole session token (e.g. from a provider
 // configuration that uses token-based auth instead of username/password).
-func NewClientWithToken(baseURL, token, dataSource string, httpClient *http.Client) *Client {
+func NewClientWithToken(baseURL, token, dataSource string, httpClient *http.Client, opts ...Option) *Client {
 	if httpClient == nil {
 		httpClient = &http.Client{Timeout: 30 * time.Second}
 	}
-	return &Client{
-		baseURL:    strings.TrimRight(baseURL, "/"),
+	c := &Client{
+		baseURL:    normalizeBaseURL(baseURL),
 		httpClient: httpClient,
 		authToken:  token,
 		dataSource: dataSource,
 	}
+	c.applyOptions(opts)
+	return c
 }
 
 // Authenticate performs the Guacamole token exchange (POST /api/tokens) and
@@ -77,39 +149,110 @@ func (c *Client) Authenticate(ctx context.Context, username, password string) er
 	form := url.Values{}
 	form.Set("username", username)
 	form.Set("password", password)
+	return c.authenticate(ctx, form)
+}
+
+// AuthenticateWithJSONToken logs in through the guacamole-auth-json
+// extension by POSTing encryptedData (the signed and encrypted, base64-encoded
+// JSON blob) as the "data" form field of /api/tokens. The returned token and
+// data source are stored exactly as with Authenticate.
+func (c *Client) AuthenticateWithJSONToken(ctx context.Context, encryptedData string) error {
+	form := url.Values{}
+	form.Set("data", encryptedData)
+	return c.authenticate(ctx, form)
+}
+
+// authenticate POSTs form to /api/tokens and stores the resulting session.
+func (c *Client) authenticate(ctx context.Context, form url.Values) error {
+	auth, err := c.exchangeToken(ctx, form)
+	if err != nil {
+		return err
+	}
+	c.authToken = auth.AuthToken
+	c.dataSource = auth.DataSource
+	c.username = auth.Username
+	c.availableDataSources = auth.AvailableDataSources
+	return nil
+}
+
+// exchangeToken POSTs form to /api/tokens and returns the decoded response
+// without modifying the client's session.
+func (c *Client) exchangeToken(ctx context.Context, form url.Values) (*AuthResponse, error) {
+	ctx, cancel := c.withCategoryTimeout(ctx, timeoutAuth)
+	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		c.baseURL+"/api/tokens",
+		c.baseURL+c.apiPath("/tokens"),
 		strings.NewReader(form.Encode()),
 	)
 	if err != nil {
-		return fmt.Errorf("guacamole: build auth request: %w", err)
+		return nil, fmt.Errorf("guacamole: build auth request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", jsonContentType)
+	c.setRequestID(ctx, req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
-		return fmt.Errorf("guacamole: auth request: %w", err)
+		return nil, fmt.Errorf("guacamole: auth request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return c.parseError(resp)
+		return nil, c.parseError(resp)
 	}
 
+	if err := expectJSON(resp); err != nil {
+		return nil, err
+	}
 	var auth AuthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
-		return fmt.Errorf("guacamole: decode auth response: %w", err)
+		return nil, fmt.Errorf("guacamole: decode auth response: %w", err)
 	}
+	return &auth, nil
+}
 
-	c.authToken = auth.AuthToken
-	c.dataSource = auth.DataSource
-	return nil
+// VerifyCredentials checks username and password against the server without
+// touching the client's own session, e.g. for step-up confirmation while
+// logged in as an administrator. It returns true when the credentials are
+// accepted and false when the server rejects them as INVALID_CREDENTIALS; any
+// other failure is returned as an error. The session created by a successful
+// check is logged out again on a best-effort basis.
+func (c *Client) VerifyCredentials(ctx context.Context, username, password string) (bool, error) {
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+	auth, err := c.exchangeToken(ctx, form)
+	if IsInvalidCredentials(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("guacamole: verify credentials for %s: %w", username, err)
+	}
+
+	probe := *c
+	probe.authToken = auth.AuthToken
+	_ = probe.Logout(ctx)
+	return true, nil
 }
 
-// Logout invalidates the current session token (DELETE /api/session).
+// Logout invalidates the current session token (DELETE /api/session). It is a
+// no-op when the client holds no token. On success the stored token and data
+// source are cleared so the client cannot accidentally be reused with a dead
+// session; call Authenticate again to start a new one.
 func (c *Client) Logout(ctx context.Context) error {
-	return c.delete(ctx, "/api/session")
+	if c.authToken == "" {
+		return nil
+	}
+	if err := c.delete(ctx, c.apiPath("/session")); err != nil {
+		return err
+	}
+	c.authToken = ""
+	c.dataSource = ""
+	c.username = ""
+	c.availableDataSources = nil
+	c.clearETags()
+	return nil
 }
 
 // DataSource returns the data source string that was received during
@@ -118,6 +261,61 @@ func (c *Client) DataSource() string {
 	return c.dataSource
 }
 
+// AvailableDataSources returns the data sources reported by Authenticate
+// (e.g. ["postgresql", "ldap"]). It is empty for clients created with
+// NewClientWithToken.
+func (c *Client) AvailableDataSources() []string {
+	return append([]string(nil), c.availableDataSources...)
+}
+
+// SwitchDataSource makes name the active data source for subsequent calls
+// without re-authenticating. Guacamole session tokens are valid across all of
+// the data sources listed in the authentication response, so name must be one
+// of AvailableDataSources.
+func (c *Client) SwitchDataSource(ctx context.Context, name string) error {
+	for _, ds := range c.availableDataSources {
+		if ds == name {
+			c.dataSource = name
+			c.clearETags()
+			return nil
+		}
+	}
+	return fmt.Errorf("guacamole: data source %q is not available (available: %v)", name, c.availableDataSources)
+}
+
+// CheckDataSources probes every data source in AvailableDataSources with a
+// cheap read of its ROOT connection group, without changing the active data
+// source, so that multi-source tooling can pick one that works. The result
+// maps each data source to nil when the read succeeded or to the error it
+// failed with (e.g. a permission denial). The returned error is non-nil only
+// when there is nothing to check, as for clients created with
+// NewClientWithToken.
+func (c *Client) CheckDataSources(ctx context.Context) (map[string]error, error) {
+	if len(c.availableDataSources) == 0 {
+		return nil, fmt.Errorf("guacamole: check data sources: no available data sources; call Authenticate first")
+	}
+	var mu sync.Mutex
+	results := make(map[string]error, len(c.availableDataSources))
+	forEachBounded(dedupe(c.availableDataSources), bulkWorkers, func(ds string) {
+		var root ConnectionGroup
+		err := c.get(ctx, c.dataPathIn(ds, "connectionGroups", RootConnectionGroupIdentifier), &root)
+		if err != nil {
+			err = fmt.Errorf("guacamole: check data source %s: %w", ds, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[ds] = err
+	})
+	return results, nil
+}
+
+// Username returns the name of the authenticated user as reported by
+// Authenticate, without a round-trip to GetSelf. It is empty for clients
+// created with NewClientWithToken and after Logout.
+func (c *Client) Username() string {
+	return c.username
+}
+
 // AuthToken returns the current authentication token.
 func (c *Client) AuthToken() string {
 	return c.authToken
@@ -130,13 +328,53 @@ func (c *Client) AuthToken() string {
 // Example: dataPath("users", "bob@example.com") →
 //
 //	"/api/session/data/postgresql/users/bob%40example.com"
+//
+// The "/api" prefix can be changed with WithAPIPrefix.
 func (c *Client) dataPath(segments ...string) string {
-	parts := make([]string, 0, len(segments)+2)
-	parts = append(parts, url.PathEscape(c.dataSource))
+	return c.dataPathIn(c.dataSource, segments...)
+}
+
+// dataPathIn is dataPath for an explicit data source rather than the one
+// selected at authentication, e.g. for objects owned by an extension.
+func (c *Client) dataPathIn(dataSource string, segments ...string) string {
+	parts := make([]string, 0, len(segments)+1)
+	parts = append(parts, escapeSegment(dataSource))
 	for _, s := range segments {
-		parts = append(parts, url.PathEscape(s))
+		if s != "" {
+			parts = append(parts, escapeSegment(s))
+		}
+	}
+	return c.apiPath("/session/data/" + strings.Join(parts, "/"))
+}
+
+// escapeSegment percent-encodes s for use as a single path segment. Unlike
+// url.PathEscape alone it also encodes the dot segments "." and "..", which
+// would otherwise be collapsed by path cleaning on either end of the request.
+func escapeSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
+
+// apiPath prefixes p, which must start with "/", with the API prefix.
+func (c *Client) apiPath(p string) string {
+	switch c.apiPrefix {
+	case "":
+		return defaultAPIPrefix + p
+	case "/":
+		return p
+	}
+	return c.apiPrefix + p
+}
+
+// apiRelative strips the base URL path and the API prefix from a request URL
+// path, yielding e.g. "/session/data/postgresql/users".
+func (c *Client) apiRelative(urlPath string) string {
+	if u, err := url.Parse(c.baseURL); err == nil {
+		urlPath = strings.TrimPrefix(urlPath, u.Path)
 	}
-	return "/api/session/data/" + path.Join(parts...)
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(urlPath, c.apiPath("")), "/")
 }
 
 // ── HTTP helpers ─────────────────────────────────────────────────────────────
@@ -148,11 +386,16 @@ func (c *Client) get(ctx context.Context, path string, out interface{}) error {
 		return err
 	}
 	defer resp.Body.Close()
+	if err := expectJSON(resp); err != nil {
+		return err
+	}
 	return json.NewDecoder(resp.Body).Decode(out)
 }
 
 // post makes a POST request with a JSON body and decodes the JSON response
-// into out (may be nil if no response body is expected).
+// into out (may be nil if no response body is expected). Some extensions
+// answer with a 2xx and no body at all; out is then left untouched rather
+// than reporting a decode error.
 func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
 	resp, err := c.do(ctx, http.MethodPost, path, body)
 	if err != nil {
@@ -162,7 +405,13 @@ func (c *Client) post(ctx context.Context, path string, body, out interface{}) e
 	if out == nil {
 		return nil
 	}
-	return json.NewDecoder(resp.Body).Decode(out)
+	if err := expectJSON(resp); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
 }
 
 // put makes a PUT request with a JSON body. Guacamole returns 204 No Content
@@ -197,12 +446,32 @@ func (c *Client) patch(ctx context.Context, path string, ops []PatchOperation) e
 	return nil
 }
 
+// jsonContentType is the media type of Guacamole API requests and responses.
+const jsonContentType = "application/json"
+
 // do is the low-level HTTP request method. It serialises body to JSON (if
-// non-nil), attaches the auth token header, executes the request, and returns
-// an error for any non-2xx response.
+// non-nil; url.Values bodies are form-encoded instead, as some extension
+// endpoints require), attaches the auth token header, executes the request, and returns
+// an error for any non-2xx response. Requests made without a token fail fast
+// with ErrNotAuthenticated unless WithAllowAnonymous is set. The request
+// asks for a JSON response; use doAccept for endpoints that serve other media
+// types.
 func (c *Client) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	return c.doAccept(ctx, method, path, body, jsonContentType)
+}
+
+// doAccept is do with an explicit Accept header value.
+func (c *Client) doAccept(ctx context.Context, method, path string, body interface{}, accept string) (*http.Response, error) {
+	if c.authToken == "" && !c.allowAnonymous && path != c.apiPath("/tokens") {
+		return nil, ErrNotAuthenticated
+	}
+
 	var bodyReader io.Reader
-	if body != nil {
+	contentType := jsonContentType
+	if form, ok := body.(url.Values); ok {
+		bodyReader = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	} else if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("guacamole: marshal request body: %w", err)
@@ -210,32 +479,146 @@ func (c *Client) do(ctx context.Context, method, path string, body interface{})
 		bodyReader = bytes.NewReader(data)
 	}
 
+	category := timeoutWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		category = timeoutRead
+	}
+	ctx, cancel := c.withCategoryTimeout(ctx, category)
+
 	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("guacamole: build request: %w", err)
 	}
 
 	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 	}
+	req.Header.Set("Accept", accept)
 	if c.authToken != "" {
 		req.Header.Set("Guacamole-Token", c.authToken)
 	}
+	c.setRequestID(ctx, req)
+	c.setIfNoneMatch(ctx, req, path)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("guacamole: %s %s: %w", method, path, err)
 	}
 
+	if resp.StatusCode == http.StatusNotModified && req.Header.Get("If-None-Match") != "" {
+		cancel()
+		resp.Body.Close()
+		return nil, ErrNotModified
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer cancel()
 		defer resp.Body.Close()
 		return nil, c.parseError(resp)
 	}
 
+	c.recordETag(ctx, resp, path)
+	if c.responseHook != nil {
+		c.responseHook(resp)
+	}
+
+	// The deadline must outlive do, since callers read the body afterwards;
+	// release it when the body is closed.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 	return resp, nil
 }
 
-// parseError reads an API error response body and returns an *APIError.
+// expectJSON returns an error wrapping ErrUnexpectedContentType when resp,
+// which is about to be decoded as JSON, declares a non-JSON Content-Type.
+// Bodiless 204 responses and responses without a Content-Type are accepted.
+func expectJSON(resp *http.Response) error {
+	ct := resp.Header.Get("Content-Type")
+	if resp.StatusCode == http.StatusNoContent || ct == "" {
+		return nil
+	}
+	if mt, _, err := mime.ParseMediaType(ct); err == nil && (mt == jsonContentType || strings.HasSuffix(mt, "+json")) {
+		return nil
+	}
+	return fmt.Errorf("%w %q from %s %s; expected %s (is a proxy answering in place of Guacamole?)",
+		ErrUnexpectedContentType, ct, resp.Request.Method, resp.Request.URL.Path, jsonContentType)
+}
+
+// setRequestID sets the correlation header configured by WithRequestIDHeader,
+// if any, from ctx.
+func (c *Client) setRequestID(ctx context.Context, req *http.Request) {
+	if c.requestIDFunc == nil {
+		return
+	}
+	if id := c.requestIDFunc(ctx); id != "" {
+		req.Header.Set(c.requestIDHeader, id)
+	}
+}
+
+// send executes req with the underlying *http.Client, reporting the outcome
+// to the metrics observer if one is configured, and decompresses a gzip
+// response body the transport left encoded.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	start := c.clk().Now()
+	resp, err := c.httpClient.Do(req)
+	if c.metrics != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		c.metrics.ObserveRequest(operationLabel(req.Method, c.apiRelative(req.URL.Path)), status, c.clk().Now().Sub(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := decompress(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decompress replaces a gzip-encoded response body with a decompressing
+// reader. Go's transport normally does this itself, but not when compression
+// was requested by a custom transport or applied unasked by a proxy.
+func decompress(resp *http.Response) error {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	switch {
+	case err == io.EOF:
+		// An empty body, e.g. 204 No Content; nothing to decompress.
+	case err != nil:
+		resp.Body.Close()
+		return fmt.Errorf("guacamole: decompress response: %w", err)
+	default:
+		resp.Body = &gzipBody{Reader: zr, body: resp.Body}
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// gzipBody reads through a gzip.Reader and closes the underlying body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// parseError reads an API error response body and returns an *APIError. A
+// body that is not a Guacamole error object becomes the message verbatim.
+// Either way the message is redacted first, since the server or a proxy may
+// echo credentials in the body or in the message itself. A
+// "statusCode" embedded in the body is kept as BodyStatus; HTTPStatus is
+// always the status of the response itself.
 func (c *Client) parseError(resp *http.Response) error {
 	apiErr := &APIError{HTTPStatus: resp.StatusCode}
 	body, err := io.ReadAll(resp.Body)
@@ -243,8 +626,18 @@ func (c *Client) parseError(resp *http.Response) error {
 		apiErr.Message = http.StatusText(resp.StatusCode)
 		return apiErr
 	}
-	if err := json.Unmarshal(body, apiErr); err != nil {
-		apiErr.Message = string(body)
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = string(redactBody(body))
+	} else {
+		apiErr.Message = string(redactPairs([]byte(apiErr.Message)))
+	}
+	var extended struct {
+		StatusCode json.Number `json:"statusCode"`
+	}
+	if json.Unmarshal(body, &extended) == nil {
+		if n, err := extended.StatusCode.Int64(); err == nil {
+			apiErr.BodyStatus = int(n)
+		}
 	}
 	return apiErr
 }
@@ -1,9 +1,12 @@
 package guacamole
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -47,6 +50,183 @@ func TestAuthenticate_success(t *testing.T) {
 	}
 }
 
+func TestAuthenticateWithJSONToken(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPost)
+		assertPath(t, r, "/api/tokens")
+		assertHeader(t, r, "Content-Type", "application/x-www-form-urlencoded")
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		if got := r.FormValue("data"); got != "c2lnbmVk+ZW5jcnlwdGVk==" {
+			t.Errorf("data: got %q, want %q", got, "c2lnbmVk+ZW5jcnlwdGVk==")
+		}
+		if r.Form.Has("username") || r.Form.Has("password") {
+			t.Errorf("unexpected username/password fields: %v", r.Form)
+		}
+		writeJSON(t, w, AuthResponse{AuthToken: "jsontoken", DataSource: "json"})
+	})
+	c.authToken = ""
+	c.dataSource = ""
+
+	if err := c.AuthenticateWithJSONToken(context.Background(), "c2lnbmVk+ZW5jcnlwdGVk=="); err != nil {
+		t.Fatalf("AuthenticateWithJSONToken: %v", err)
+	}
+	if c.authToken != "jsontoken" {
+		t.Errorf("authToken: got %q, want %q", c.authToken, "jsontoken")
+	}
+	if c.dataSource != "json" {
+		t.Errorf("dataSource: got %q, want %q", c.dataSource, "json")
+	}
+}
+
+func TestAuthenticate_stores_username(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, AuthResponse{AuthToken: "tok", DataSource: "mysql", Username: "guacadmin"})
+	})
+	c.authToken = ""
+	c.dataSource = ""
+
+	if c.Username() != "" {
+		t.Errorf("Username before Authenticate: got %q, want empty", c.Username())
+	}
+	if err := c.Authenticate(context.Background(), "GuacAdmin", "secret"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	// The server's canonical username is stored, not the login input.
+	if c.Username() != "guacadmin" {
+		t.Errorf("Username: got %q, want %q", c.Username(), "guacadmin")
+	}
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		want    bool
+		wantErr bool
+	}{
+		{"valid", func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost:
+				writeJSON(t, w, AuthResponse{AuthToken: "probe-token", DataSource: "mysql"})
+			case http.MethodDelete:
+				assertHeader(t, r, "Guacamole-Token", "probe-token")
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}, true, false},
+		{"invalid", func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+			writeAPIError(t, w, http.StatusForbidden, ErrTypeInvalidCredentials, "Invalid login.")
+		}, false, false},
+		{"server error", func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+			writeAPIError(t, w, http.StatusInternalServerError, "INTERNAL_ERROR", "boom")
+		}, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				tc.handler(t, w, r)
+			})
+			c.username = "admin"
+			got, err := c.VerifyCredentials(context.Background(), "alice", "pw")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("VerifyCredentials: err=%v, wantErr=%v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("VerifyCredentials: got %v, want %v", got, tc.want)
+			}
+			if c.AuthToken() != "test-token" || c.DataSource() != "postgresql" || c.Username() != "admin" {
+				t.Errorf("caller session changed: token=%q dataSource=%q username=%q",
+					c.AuthToken(), c.DataSource(), c.Username())
+			}
+		})
+	}
+}
+
+func TestSwitchDataSource(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tokens":
+			writeJSON(t, w, AuthResponse{
+				AuthToken:            "tok",
+				DataSource:           "postgresql",
+				AvailableDataSources: []string{"postgresql", "ldap"},
+			})
+		case "/api/session/data/ldap/users":
+			writeJSON(t, w, map[string]User{})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	c.authToken = ""
+	if err := c.Authenticate(context.Background(), "admin", "secret"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if err := c.SwitchDataSource(context.Background(), "ldap"); err != nil {
+		t.Fatalf("SwitchDataSource(ldap): %v", err)
+	}
+	if c.DataSource() != "ldap" {
+		t.Errorf("DataSource: got %q, want %q", c.DataSource(), "ldap")
+	}
+	if c.AuthToken() != "tok" {
+		t.Errorf("AuthToken: got %q, want unchanged %q", c.AuthToken(), "tok")
+	}
+	if _, err := c.ListUsers(context.Background()); err != nil {
+		t.Errorf("ListUsers after switch: %v", err)
+	}
+
+	if err := c.SwitchDataSource(context.Background(), "mysql"); err == nil {
+		t.Error("SwitchDataSource(mysql): expected error for unavailable source, got nil")
+	}
+	if c.DataSource() != "ldap" {
+		t.Errorf("DataSource after rejected switch: got %q, want %q", c.DataSource(), "ldap")
+	}
+}
+
+func TestCheckDataSources(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tokens":
+			writeJSON(t, w, AuthResponse{
+				AuthToken:            "tok",
+				DataSource:           "postgresql",
+				AvailableDataSources: []string{"postgresql", "ldap"},
+			})
+		case "/api/session/data/postgresql/connectionGroups/ROOT":
+			writeJSON(t, w, ConnectionGroup{Identifier: "ROOT", Name: "ROOT"})
+		case "/api/session/data/ldap/connectionGroups/ROOT":
+			writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission denied.")
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	c.authToken = ""
+	if _, err := c.CheckDataSources(context.Background()); err == nil {
+		t.Error("CheckDataSources before Authenticate: expected error, got nil")
+	}
+	if err := c.Authenticate(context.Background(), "admin", "secret"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	got, err := c.CheckDataSources(context.Background())
+	if err != nil {
+		t.Fatalf("CheckDataSources: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("results: got %v, want two entries", got)
+	}
+	if got["postgresql"] != nil {
+		t.Errorf("postgresql: got %v, want nil", got["postgresql"])
+	}
+	if !IsPermissionDenied(got["ldap"]) {
+		t.Errorf("ldap: got %v, want permission denied", got["ldap"])
+	}
+	if c.DataSource() != "postgresql" {
+		t.Errorf("DataSource: got %q, want unchanged %q", c.DataSource(), "postgresql")
+	}
+}
+
 func TestAuthenticate_error(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Invalid credentials.")
@@ -73,6 +253,37 @@ func TestLogout(t *testing.T) {
 	if err := c.Logout(context.Background()); err != nil {
 		t.Fatalf("Logout: %v", err)
 	}
+	if c.AuthToken() != "" {
+		t.Errorf("AuthToken after logout: got %q, want empty", c.AuthToken())
+	}
+	if c.DataSource() != "" {
+		t.Errorf("DataSource after logout: got %q, want empty", c.DataSource())
+	}
+	if c.Username() != "" {
+		t.Errorf("Username after logout: got %q, want empty", c.Username())
+	}
+}
+
+func TestLogout_without_token_is_noop(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s request", r.Method, r.URL.Path)
+	})
+	c.authToken = ""
+	if err := c.Logout(context.Background()); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+}
+
+func TestLogout_failure_keeps_token(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(t, w, http.StatusInternalServerError, "INTERNAL_ERROR", "boom")
+	})
+	if err := c.Logout(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if c.AuthToken() != "test-token" {
+		t.Errorf("AuthToken: got %q, want unchanged %q", c.AuthToken(), "test-token")
+	}
 }
 
 // ── Error handling ─────────────────────────────────────────────────────────────
@@ -121,6 +332,28 @@ func TestAPIError_error_message(t *testing.T) {
 	}
 }
 
+func TestIsAlreadyExists(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"bad request duplicate", &APIError{HTTPStatus: 400, Type: ErrTypeBadRequest, Message: `User "bob" already exists.`}, true},
+		{"conflict", &APIError{HTTPStatus: 409, Message: "Conflict"}, true},
+		{"wrapped", fmt.Errorf("create: %w", &APIError{HTTPStatus: 400, Type: ErrTypeBadRequest, Message: "Already exists"}), true},
+		{"other bad request", &APIError{HTTPStatus: 400, Type: ErrTypeBadRequest, Message: "Invalid name"}, false},
+		{"not found", &APIError{HTTPStatus: 404, Type: ErrTypeNotFound, Message: "already exists"}, false},
+		{"nil", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsAlreadyExists(tc.err); got != tc.want {
+				t.Errorf("IsAlreadyExists: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestIsNotFound_nil_error(t *testing.T) {
 	if IsNotFound(nil) {
 		t.Error("IsNotFound(nil): got true, want false")
@@ -145,6 +378,80 @@ func TestAuthTokenSentOnRequests(t *testing.T) {
 	}
 }
 
+func TestDo_not_authenticated(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s request without a token", r.Method, r.URL.Path)
+	})
+	c.authToken = ""
+	_, err := c.ListConnections(context.Background())
+	if !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("err: got %v, want ErrNotAuthenticated", err)
+	}
+}
+
+func TestDo_allow_anonymous(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Guacamole-Token"); got != "" {
+			t.Errorf("Guacamole-Token: got %q, want none", got)
+		}
+		writeJSON(t, w, map[string]Connection{})
+	})
+	c.authToken = ""
+	WithAllowAnonymous()(c)
+	if _, err := c.ListConnections(context.Background()); err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+}
+
+// ── Base URL normalization ─────────────────────────────────────────────────────
+
+func TestNormalizeBaseURL(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"http://host/guacamole", "http://host/guacamole"},
+		{"http://host/guacamole/", "http://host/guacamole"},
+		{"http://host//guacamole", "http://host/guacamole"},
+		{"http://host//guacamole//", "http://host/guacamole"},
+		{"http://host/a//b/", "http://host/a/b"},
+		{"http://host/", "http://host"},
+		{"http://host", "http://host"},
+	}
+	for _, tc := range cases {
+		if got := normalizeBaseURL(tc.in); got != tc.want {
+			t.Errorf("normalizeBaseURL(%q): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNewClient_messy_base_url_request_paths(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/guacamole/api/tokens" {
+			writeJSON(t, w, AuthResponse{AuthToken: "tok", DataSource: "mysql"})
+			return
+		}
+		writeJSON(t, w, map[string]Connection{})
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, base := range []string{"/guacamole/", "//guacamole", "//guacamole//"} {
+		paths = nil
+		c := NewClientWithHTTPClient(srv.URL+base, srv.Client())
+		if err := c.Authenticate(context.Background(), "admin", "secret"); err != nil {
+			t.Fatalf("%s: Authenticate: %v", base, err)
+		}
+		if _, err := c.ListConnections(context.Background()); err != nil {
+			t.Fatalf("%s: ListConnections: %v", base, err)
+		}
+		want := []string{"/guacamole/api/tokens", "/guacamole/api/session/data/mysql/connections"}
+		if strings.Join(paths, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: paths: got %v, want %v", base, paths, want)
+		}
+	}
+}
+
 // ── URL encoding ───────────────────────────────────────────────────────────────
 
 func TestDataPath_url_encodes_special_chars(t *testing.T) {
@@ -167,6 +474,54 @@ func TestDataPath_url_encodes_special_chars(t *testing.T) {
 	}
 }
 
+func TestDataPath_tricky_data_source_names(t *testing.T) {
+	cases := []struct {
+		name    string
+		escaped string
+	}{
+		{"postgresql", "postgresql"},
+		{"ldap.corp", "ldap.corp"},
+		{"team/a", "team%2Fa"},
+		{"my source", "my%20source"},
+		{".", "%2E"},
+		{"..", "%2E%2E"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Client{dataSource: tc.name}
+			want := "/api/session/data/" + tc.escaped + "/users/alice"
+			if got := c.dataPath("users", "alice"); got != want {
+				t.Errorf("data source segment: got %q, want %q", got, want)
+			}
+			// Identifiers must be encoded exactly like the data source.
+			c = &Client{dataSource: "postgresql"}
+			want = "/api/session/data/postgresql/users/" + tc.escaped
+			if got := c.dataPath("users", tc.name); got != want {
+				t.Errorf("identifier segment: got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDataPath_tricky_data_source_on_the_wire(t *testing.T) {
+	for _, ds := range []string{"ldap.corp", "team/a", "my source", ".."} {
+		t.Run(ds, func(t *testing.T) {
+			var got string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.EscapedPath()
+				writeJSON(t, w, map[string]User{})
+			})
+			c.dataSource = ds
+			if _, err := c.ListUsers(context.Background()); err != nil {
+				t.Fatalf("ListUsers: %v", err)
+			}
+			if want := "/api/session/data/" + escapeSegment(ds) + "/users"; got != want {
+				t.Errorf("request path: got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
 func TestGetUser_special_chars_url_encoded(t *testing.T) {
 	const username = "bob@example.com"
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
@@ -195,6 +550,108 @@ func TestPostSetsContentTypeJSON(t *testing.T) {
 	_, _ = c.CreateUser(context.Background(), User{Username: "u"})
 }
 
+// ── Content negotiation ────────────────────────────────────────────────────────
+
+func TestDo_sets_accept_json(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertHeader(t, r, "Accept", "application/json")
+		writeJSON(t, w, map[string]User{})
+	})
+	if _, err := c.ListUsers(context.Background()); err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+}
+
+func TestGet_html_response_is_unexpected_content_type(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body>Please log in to the corporate proxy</body></html>"))
+	})
+	_, err := c.ListUsers(context.Background())
+	if !errors.Is(err, ErrUnexpectedContentType) {
+		t.Fatalf("err: got %v, want ErrUnexpectedContentType", err)
+	}
+	if !strings.Contains(err.Error(), "text/html") {
+		t.Errorf("error does not name the content type: %v", err)
+	}
+}
+
+func TestGet_json_content_type_variants_accepted(t *testing.T) {
+	for _, ct := range []string{"application/json", "application/json;charset=UTF-8", "application/problem+json", ""} {
+		t.Run(ct, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{ct}
+				_, _ = w.Write([]byte(`{"alice":{"username":"alice"}}`))
+			})
+			if _, err := c.ListUsers(context.Background()); err != nil {
+				t.Errorf("ListUsers: %v", err)
+			}
+		})
+	}
+}
+
+// ── Compressed responses ───────────────────────────────────────────────────────
+
+// gzipHandler writes body gzip-compressed with the given status, regardless
+// of what the request accepted, as some proxies do.
+func gzipHandler(t *testing.T, status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+		zw := gzip.NewWriter(w)
+		if _, err := zw.Write([]byte(body)); err != nil {
+			t.Errorf("gzip write: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Errorf("gzip close: %v", err)
+		}
+	}
+}
+
+// withoutTransportDecompression stops the transport from decompressing
+// responses itself, as custom transports may.
+func withoutTransportDecompression(c *Client) {
+	tr := c.httpClient.Transport.(*http.Transport).Clone()
+	tr.DisableCompression = true
+	c.httpClient = &http.Client{Transport: tr}
+}
+
+func TestGet_gzip_response_decoded(t *testing.T) {
+	c := newTestClient(t, gzipHandler(t, http.StatusOK, `{"alice":{"username":"alice"}}`))
+	withoutTransportDecompression(c)
+	got, err := c.ListUsers(context.Background())
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if got["alice"].Username != "alice" {
+		t.Errorf("users: got %+v", got)
+	}
+}
+
+func TestParseError_gzip_body(t *testing.T) {
+	c := newTestClient(t, gzipHandler(t, http.StatusNotFound, `{"message":"No such user","type":"NOT_FOUND"}`))
+	withoutTransportDecompression(c)
+	_, err := c.GetUser(context.Background(), "ghost")
+	if !IsNotFound(err) {
+		t.Fatalf("err: got %v, want NOT_FOUND", err)
+	}
+	if !strings.Contains(err.Error(), "No such user") {
+		t.Errorf("message not decoded: %v", err)
+	}
+}
+
+func TestDelete_gzip_header_with_empty_body(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	withoutTransportDecompression(c)
+	if err := c.DeleteUser(context.Background(), "alice"); err != nil {
+		t.Errorf("DeleteUser: %v", err)
+	}
+}
+
 // ── Non-2xx without JSON body ──────────────────────────────────────────────────
 
 func TestParseError_non_json_body(t *testing.T) {
@@ -218,6 +675,44 @@ func TestParseError_non_json_body(t *testing.T) {
 	}
 }
 
+func TestParseError_body_status_code(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		message    string
+		errType    string
+		bodyStatus int
+	}{
+		{"standard body", `{"message":"No such user.","type":"NOT_FOUND"}`, "No such user.", ErrTypeNotFound, 0},
+		{"embedded status", `{"message":"No such user.","type":"NOT_FOUND","statusCode":404}`, "No such user.", ErrTypeNotFound, 404},
+		{"quoted status", `{"message":"Vault unreachable.","statusCode":"503"}`, "Vault unreachable.", "", 503},
+		{"malformed status", `{"message":"Vault unreachable.","statusCode":"soon"}`, "Vault unreachable.", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			_, err := c.ListUsers(context.Background())
+			var apiErr *APIError
+			if !isAPIError(err, &apiErr) {
+				t.Fatalf("expected *APIError in chain, got %T: %v", err, err)
+			}
+			if apiErr.HTTPStatus != http.StatusBadGateway {
+				t.Errorf("HTTPStatus: got %d, want %d", apiErr.HTTPStatus, http.StatusBadGateway)
+			}
+			if apiErr.BodyStatus != tt.bodyStatus {
+				t.Errorf("BodyStatus: got %d, want %d", apiErr.BodyStatus, tt.bodyStatus)
+			}
+			if apiErr.Message != tt.message || apiErr.Type != tt.errType {
+				t.Errorf("message/type: got %q/%q, want %q/%q", apiErr.Message, apiErr.Type, tt.message, tt.errType)
+			}
+		})
+	}
+}
+
 // isAPIError walks the error chain to find an *APIError.
 func isAPIError(err error, target **APIError) bool {
 	for err != nil {
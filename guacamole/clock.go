@@ -0,0 +1,44 @@
+package guacamole
+
+import (
+	"context"
+	"time"
+)
+
+// clock abstracts the time source used for polling sleeps (as in
+// WaitConnectionInactive) and for timing requests reported to a
+// MetricsObserver, so that tests can substitute a manually-advanced fake
+// instead of really sleeping.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the default clock, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clk returns the client's clock, falling back to the real clock when none has
+// been injected.
+func (c *Client) clk() clock {
+	if c.clock == nil {
+		return realClock{}
+	}
+	return c.clock
+}
+
+// sleep blocks for d according to the client's clock, returning early with the
+// context's error if ctx is cancelled first.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clk().After(d):
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually-advanced clock. Channels returned by After only fire
+// when Advance moves the current time past their deadline.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+	added   chan struct{}
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		added: make(chan struct{}, 64),
+	}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, fakeWaiter{deadline: f.now.Add(d), ch: ch})
+	f.added <- struct{}{}
+	return ch
+}
+
+// Advance moves the clock forward by d and fires every waiter whose deadline
+// has been reached.
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if !w.deadline.After(f.now) {
+			w.ch <- f.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	f.waiters = remaining
+}
+
+// waitForWaiter blocks until some goroutine has called After, so that tests
+// advance the clock only once the code under test is actually waiting.
+func (f *fakeClock) waitForWaiter(t *testing.T) {
+	t.Helper()
+	select {
+	case <-f.added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a clock waiter")
+	}
+}
+
+// withClock injects clk into c and returns c for chaining.
+func withClock(c *Client, clk clock) *Client {
+	c.clock = clk
+	return c
+}
+
+func TestClient_defaults_to_real_clock(t *testing.T) {
+	c := NewClient("http://localhost")
+	if _, ok := c.clk().(realClock); !ok {
+		t.Errorf("clk: got %T, want realClock", c.clk())
+	}
+}
+
+func TestSleep_waits_for_fake_clock(t *testing.T) {
+	fc := newFakeClock()
+	c := withClock(&Client{}, fc)
+
+	done := make(chan error, 1)
+	go func() { done <- c.sleep(context.Background(), 5*time.Second) }()
+	fc.waitForWaiter(t)
+
+	fc.Advance(4 * time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("sleep returned early after 4s (err=%v)", err)
+	default:
+	}
+
+	fc.Advance(time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("sleep: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sleep did not return after clock advanced past deadline")
+	}
+}
+
+func TestSleep_context_cancelled(t *testing.T) {
+	fc := newFakeClock()
+	c := withClock(&Client{}, fc)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- c.sleep(ctx, time.Hour) }()
+	fc.waitForWaiter(t)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("sleep: got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sleep did not return after context cancellation")
+	}
+}
+
+func TestMetrics_duration_uses_client_clock(t *testing.T) {
+	fc := newFakeClock()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fc.Advance(1500 * time.Millisecond)
+		writeJSON(t, w, User{Username: "alice"})
+	})
+	withClock(c, fc)
+	obs := &recordingObserver{}
+	WithMetrics(obs)(c)
+
+	if _, err := c.GetUser(context.Background(), "alice"); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if len(obs.durs) != 1 || obs.durs[0] != 1500*time.Millisecond {
+		t.Errorf("durations: got %v, want [1.5s]", obs.durs)
+	}
+}
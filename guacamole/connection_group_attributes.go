@@ -0,0 +1,37 @@
+package guacamole
+
+import "fmt"
+
+// ConnectionGroupAttributeSessionAffinity is the connection group attribute
+// that, on BALANCING groups, routes a user's later sessions to the same member
+// connection they used first.
+const ConnectionGroupAttributeSessionAffinity = "enable-session-affinity"
+
+// SessionAffinity reports whether session affinity is enabled on g.
+func (g *ConnectionGroup) SessionAffinity() bool {
+	return g.Attributes[ConnectionGroupAttributeSessionAffinity] == "true"
+}
+
+// SetSessionAffinity enables or disables session affinity on g. It only has
+// an effect on BALANCING groups; Validate reports it on other group types.
+func (g *ConnectionGroup) SetSessionAffinity(enabled bool) {
+	value := ""
+	if enabled {
+		value = "true"
+	}
+	if g.Attributes == nil {
+		g.Attributes = NullableStringMap{}
+	}
+	g.Attributes[ConnectionGroupAttributeSessionAffinity] = value
+}
+
+// Validate reports attribute settings that Guacamole accepts but ignores for
+// g's type, such as session affinity on an ORGANIZATIONAL group. Calling it
+// is optional; the server does not reject such groups.
+func (g *ConnectionGroup) Validate() error {
+	if g.SessionAffinity() && !g.IsBalancing() {
+		return fmt.Errorf("guacamole: connection group %q: session affinity only applies to %s groups, not %s",
+			g.Name, ConnectionGroupTypeBalancing, g.Type)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package guacamole
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConnectionGroup_SessionAffinity_round_trip(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		g := ConnectionGroup{Name: "pool", Type: ConnectionGroupTypeBalancing}
+		g.SetSessionAffinity(enabled)
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var decoded ConnectionGroup
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if got := decoded.SessionAffinity(); got != enabled {
+			t.Errorf("SessionAffinity after round trip: got %v, want %v", got, enabled)
+		}
+	}
+}
+
+func TestConnectionGroup_Validate_session_affinity(t *testing.T) {
+	balancing := ConnectionGroup{Name: "pool", Type: ConnectionGroupTypeBalancing}
+	balancing.SetSessionAffinity(true)
+	if err := balancing.Validate(); err != nil {
+		t.Errorf("balancing group: %v", err)
+	}
+
+	folder := ConnectionGroup{Name: "folder", Type: ConnectionGroupTypeOrganizational}
+	folder.SetSessionAffinity(true)
+	if err := folder.Validate(); err == nil {
+		t.Error("organizational group with affinity: expected error, got nil")
+	}
+
+	folder.SetSessionAffinity(false)
+	if err := folder.Validate(); err != nil {
+		t.Errorf("organizational group without affinity: %v", err)
+	}
+}
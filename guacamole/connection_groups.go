@@ -3,6 +3,8 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // ConnectionGroupTypeOrganizational is the type value for an organizational
@@ -36,9 +38,57 @@ func (c *Client) GetConnectionGroupTree(ctx context.Context, rootID string) (*Co
 	if err := c.get(ctx, c.dataPath("connectionGroups", rootID, "tree"), &result); err != nil {
 		return nil, fmt.Errorf("guacamole: get connection group tree %s: %w", rootID, err)
 	}
+	if c.treeMaxDepth > 0 && exceedsDepth(&result, c.treeMaxDepth) {
+		return nil, fmt.Errorf("guacamole: get connection group tree %s: tree nests deeper than the maximum of %d levels", rootID, c.treeMaxDepth)
+	}
 	return &result, nil
 }
 
+// exceedsDepth reports whether g has connection groups nested more than
+// depth levels below it. It stops descending once the limit is passed.
+func exceedsDepth(g *ConnectionGroup, depth int) bool {
+	for i := range g.ChildConnectionGroups {
+		if depth == 0 || exceedsDepth(&g.ChildConnectionGroups[i], depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListConnectionGroupChildren returns the immediate child connections and
+// child groups of the connection group identified by id, using a single tree
+// fetch. Returned groups do not carry their own descendants. Both slices are
+// sorted by name, then identifier, and each child's ParentIdentifier is set to
+// id if the server omitted it.
+func (c *Client) ListConnectionGroupChildren(ctx context.Context, id string) ([]Connection, []ConnectionGroup, error) {
+	tree, err := c.GetConnectionGroupTree(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conns := make([]Connection, 0, len(tree.ChildConnections))
+	for _, conn := range tree.ChildConnections {
+		if conn.ParentIdentifier == "" {
+			conn.ParentIdentifier = id
+		}
+		conns = append(conns, conn)
+	}
+	sortConnections(conns)
+
+	groups := make([]ConnectionGroup, 0, len(tree.ChildConnectionGroups))
+	for _, g := range tree.ChildConnectionGroups {
+		if g.ParentIdentifier == "" {
+			g.ParentIdentifier = id
+		}
+		g.ChildConnections = nil
+		g.ChildConnectionGroups = nil
+		groups = append(groups, g)
+	}
+	sortConnectionGroups(groups)
+
+	return conns, groups, nil
+}
+
 // CreateConnectionGroup creates a new connection group and returns the created
 // resource with its server-assigned identifier.
 func (c *Client) CreateConnectionGroup(ctx context.Context, group ConnectionGroup) (*ConnectionGroup, error) {
@@ -60,8 +110,12 @@ func (c *Client) GetConnectionGroup(ctx context.Context, id string) (*Connection
 
 // UpdateConnectionGroup replaces the connection group identified by id with
 // the supplied ConnectionGroup. The identifier field within group is ignored;
-// id is used.
+// id is used. Making a group its own parent is rejected without contacting
+// the server.
 func (c *Client) UpdateConnectionGroup(ctx context.Context, id string, group ConnectionGroup) error {
+	if group.ParentIdentifier == id {
+		return fmt.Errorf("guacamole: update connection group %s: a group cannot be its own parent", id)
+	}
 	if err := c.put(ctx, c.dataPath("connectionGroups", id), group); err != nil {
 		return fmt.Errorf("guacamole: update connection group %s: %w", id, err)
 	}
@@ -69,10 +123,324 @@ func (c *Client) UpdateConnectionGroup(ctx context.Context, id string, group Con
 }
 
 // DeleteConnectionGroup permanently removes the connection group with the
-// given identifier.
+// given identifier. The ROOT group cannot be deleted; attempting to do so
+// returns an error without contacting the server.
 func (c *Client) DeleteConnectionGroup(ctx context.Context, id string) error {
+	if id == RootConnectionGroupIdentifier {
+		return fmt.Errorf("guacamole: delete connection group %s: the root connection group cannot be deleted", id)
+	}
 	if err := c.delete(ctx, c.dataPath("connectionGroups", id)); err != nil {
 		return fmt.Errorf("guacamole: delete connection group %s: %w", id, err)
 	}
 	return nil
 }
+
+// PatchConnectionGroup performs a read-modify-write of the connection group
+// identified by id: it fetches the current group, passes it to fn for
+// mutation, and PUTs the result back. Because the full fetched object
+// (including its attributes) is re-sent, fields fn does not touch are
+// preserved. The Attributes map is never nil when fn is called, so fn may
+// assign to it directly. Errors are those of GetConnectionGroup or
+// UpdateConnectionGroup, which already name the group.
+func (c *Client) PatchConnectionGroup(ctx context.Context, id string, fn func(*ConnectionGroup)) error {
+	group, err := c.GetConnectionGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if group.Attributes == nil {
+		group.Attributes = NullableStringMap{}
+	}
+	fn(group)
+	return c.UpdateConnectionGroup(ctx, id, *group)
+}
+
+// MoveConnectionGroup reparents the connection group identified by id under
+// newParentID (empty means ROOT). The subtree of id is fetched first and a
+// move beneath the group itself or any of its descendants, which would create
+// a cycle, is rejected before the group is modified. The group's name, type
+// and attributes are preserved.
+func (c *Client) MoveConnectionGroup(ctx context.Context, id, newParentID string) error {
+	newParentID = parentOrRoot(newParentID)
+	if id == RootConnectionGroupIdentifier {
+		return fmt.Errorf("guacamole: move connection group %s: the root connection group cannot be moved", id)
+	}
+	tree, err := c.GetConnectionGroupTree(ctx, id)
+	if err != nil {
+		return fmt.Errorf("guacamole: move connection group %s: %w", id, err)
+	}
+	if tree.Identifier == "" {
+		tree.Identifier = id
+	}
+	cycle := false
+	tree.Walk(func(g *ConnectionGroup) bool {
+		if g.Identifier == newParentID {
+			cycle = true
+		}
+		return !cycle
+	}, nil)
+	if cycle {
+		return fmt.Errorf("guacamole: move connection group %s: %s is the group itself or one of its descendants", id, newParentID)
+	}
+	if err := c.PatchConnectionGroup(ctx, id, func(g *ConnectionGroup) {
+		g.ParentIdentifier = newParentID
+	}); err != nil {
+		return fmt.Errorf("guacamole: move connection group %s: %w", id, err)
+	}
+	return nil
+}
+
+// RenameConnectionGroup changes the name of the connection group identified
+// by id, preserving its type, parent and attributes.
+func (c *Client) RenameConnectionGroup(ctx context.Context, id, newName string) error {
+	err := c.PatchConnectionGroup(ctx, id, func(g *ConnectionGroup) {
+		g.Name = newName
+	})
+	if err != nil {
+		return fmt.Errorf("guacamole: rename connection group %s: %w", id, err)
+	}
+	return nil
+}
+
+// ResolveConnectionGroupPath returns the identifier of the connection group
+// reached by following groupPath from ROOT, where groupPath is a
+// slash-separated list of group names such as "Data Centers/East". An empty
+// path (or "/") resolves to ROOT. It is an error for a name to be missing or
+// to match more than one sibling group.
+func (c *Client) ResolveConnectionGroupPath(ctx context.Context, groupPath string) (string, error) {
+	return c.resolveConnectionGroupPath(ctx, groupPath, false)
+}
+
+// resolveConnectionGroupPath implements ResolveConnectionGroupPath, creating
+// missing organizational groups along the way when createMissing is set.
+func (c *Client) resolveConnectionGroupPath(ctx context.Context, groupPath string, createMissing bool) (string, error) {
+	groups, err := c.ListConnectionGroups(ctx)
+	if err != nil {
+		return "", fmt.Errorf("guacamole: resolve connection group path %q: %w", groupPath, err)
+	}
+	// children[parent][name] lists the identifiers of same-named siblings.
+	children := make(map[string]map[string][]string)
+	for id, g := range groups {
+		if g.Identifier == "" {
+			g.Identifier = id
+		}
+		parent := parentOrRoot(g.ParentIdentifier)
+		if children[parent] == nil {
+			children[parent] = make(map[string][]string)
+		}
+		children[parent][g.Name] = append(children[parent][g.Name], g.Identifier)
+	}
+
+	current := RootConnectionGroupIdentifier
+	for _, name := range strings.Split(strings.Trim(groupPath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		ids := children[current][name]
+		switch {
+		case len(ids) == 1:
+			current = ids[0]
+		case len(ids) > 1:
+			return "", fmt.Errorf("guacamole: resolve connection group path %q: %d groups named %q under %s", groupPath, len(ids), name, current)
+		case !createMissing:
+			return "", fmt.Errorf("guacamole: resolve connection group path %q: no group named %q under %s", groupPath, name, current)
+		default:
+			created, err := c.CreateConnectionGroup(ctx, ConnectionGroup{
+				Name:             name,
+				ParentIdentifier: current,
+				Type:             ConnectionGroupTypeOrganizational,
+			})
+			if err != nil {
+				return "", fmt.Errorf("guacamole: resolve connection group path %q: %w", groupPath, err)
+			}
+			current = created.Identifier
+		}
+	}
+	return current, nil
+}
+
+// CloneConnectionGroup recreates the subtree rooted at sourceID under the
+// group newParentID, naming the new top-level group newName. Nested groups
+// keep their names, types and attributes, and every connection is copied with
+// its attributes and parameters. Sharing profiles and permissions are not
+// copied. The clone stops as soon as ctx is cancelled or a request fails;
+// objects created up to that point are left in place. If the new top-level
+// group was created it is returned along with the error, so the caller can
+// delete the partial copy with DeleteConnectionGroup.
+func (c *Client) CloneConnectionGroup(ctx context.Context, sourceID, newParentID, newName string) (*ConnectionGroup, error) {
+	tree, err := c.GetConnectionGroupTree(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: clone connection group %s: %w", sourceID, err)
+	}
+	root, err := c.cloneGroup(ctx, tree, newParentID, newName)
+	if err != nil {
+		return root, fmt.Errorf("guacamole: clone connection group %s: %w", sourceID, err)
+	}
+	return root, nil
+}
+
+// cloneGroup creates a copy of src named name under parentID, then its
+// connections and, recursively, its child groups. Once the copy of src exists
+// it is returned even when a later request fails.
+func (c *Client) cloneGroup(ctx context.Context, src *ConnectionGroup, parentID, name string) (*ConnectionGroup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	created, err := c.CreateConnectionGroup(ctx, ConnectionGroup{
+		Name:             name,
+		ParentIdentifier: parentID,
+		Type:             src.Type,
+		Attributes:       src.Attributes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conn := range src.ChildConnections {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+		params, err := c.GetConnectionParameters(ctx, conn.Identifier)
+		if err != nil {
+			return created, err
+		}
+		if _, err := c.CreateConnection(ctx, Connection{
+			Name:             conn.Name,
+			ParentIdentifier: created.Identifier,
+			Protocol:         conn.Protocol,
+			Parameters:       params,
+			Attributes:       conn.Attributes,
+		}); err != nil {
+			return created, err
+		}
+	}
+
+	for i := range src.ChildConnectionGroups {
+		child := &src.ChildConnectionGroups[i]
+		if _, err := c.cloneGroup(ctx, child, created.Identifier, child.Name); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// IsBalancing reports whether g is a load-balancing group, whose child
+// connections are interchangeable members of a pool.
+func (g *ConnectionGroup) IsBalancing() bool {
+	return g.Type == ConnectionGroupTypeBalancing
+}
+
+// IsOrganizational reports whether g is an organizational (folder-like)
+// group.
+func (g *ConnectionGroup) IsOrganizational() bool {
+	return g.Type == ConnectionGroupTypeOrganizational
+}
+
+// WalkOptions controls how WalkWithOptions treats balancing groups.
+type WalkOptions struct {
+	// SkipBalancing stops the walk from descending into BALANCING groups.
+	// The balancing group itself is still passed to groupFn.
+	SkipBalancing bool
+	// FlattenBalancing hides BALANCING groups from groupFn and visits their
+	// children as though they belonged to the enclosing group. It has no
+	// effect when SkipBalancing is set.
+	FlattenBalancing bool
+}
+
+// Walk visits g and its descendants depth-first, in the order they appear in
+// the tree. groupFn is called for each group (starting with g itself); if it
+// returns false, that group's children are not visited. connFn is called for
+// each connection within a visited group. Either callback may be nil.
+func (g *ConnectionGroup) Walk(groupFn func(*ConnectionGroup) bool, connFn func(*Connection)) {
+	g.WalkWithOptions(WalkOptions{}, groupFn, connFn)
+}
+
+// WalkWithOptions is like Walk but applies opts to BALANCING groups
+// encountered below g. The starting group g is always passed to groupFn.
+func (g *ConnectionGroup) WalkWithOptions(opts WalkOptions, groupFn func(*ConnectionGroup) bool, connFn func(*Connection)) {
+	g.walk(opts, groupFn, connFn, true)
+}
+
+// walk implements WalkWithOptions; isStart marks the group the walk began at.
+func (g *ConnectionGroup) walk(opts WalkOptions, groupFn func(*ConnectionGroup) bool, connFn func(*Connection), isStart bool) {
+	flatten := !isStart && g.IsBalancing() && opts.FlattenBalancing && !opts.SkipBalancing
+	if !flatten && groupFn != nil && !groupFn(g) {
+		return
+	}
+	if !isStart && g.IsBalancing() && opts.SkipBalancing {
+		return
+	}
+	if connFn != nil {
+		for i := range g.ChildConnections {
+			connFn(&g.ChildConnections[i])
+		}
+	}
+	for i := range g.ChildConnectionGroups {
+		g.ChildConnectionGroups[i].walk(opts, groupFn, connFn, false)
+	}
+}
+
+// GroupStats summarises the subtree beneath a connection group.
+type GroupStats struct {
+	// Groups is the number of connection groups nested beneath the group,
+	// at any depth, excluding the group itself.
+	Groups int
+	// Connections is the number of connections in the subtree.
+	Connections int
+	// ActiveConnections is the total number of sessions currently open on
+	// those connections.
+	ActiveConnections int
+}
+
+// GetConnectionGroupStats counts the groups, connections and active sessions
+// in the subtree rooted at id using a single tree fetch. Guacamole has no
+// aggregate endpoint, and historical session counts are not included since
+// they would require a history query per connection.
+func (c *Client) GetConnectionGroupStats(ctx context.Context, id string) (*GroupStats, error) {
+	tree, err := c.GetConnectionGroupTree(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: get connection group stats %s: %w", id, err)
+	}
+	stats := &GroupStats{Groups: -1} // the root is visited but not counted
+	tree.Walk(
+		func(*ConnectionGroup) bool {
+			stats.Groups++
+			return true
+		},
+		func(conn *Connection) {
+			stats.Connections++
+			stats.ActiveConnections += conn.ActiveConnections
+		},
+	)
+	return stats, nil
+}
+
+// parentOrRoot returns parentID, substituting RootConnectionGroupIdentifier
+// when it is empty.
+func parentOrRoot(parentID string) string {
+	if parentID == "" {
+		return RootConnectionGroupIdentifier
+	}
+	return parentID
+}
+
+// lessByNameThenID orders resources by name, breaking ties by identifier.
+func lessByNameThenID(nameA, idA, nameB, idB string) bool {
+	if nameA != nameB {
+		return nameA < nameB
+	}
+	return idA < idB
+}
+
+// sortConnections sorts conns in place by name, then identifier.
+func sortConnections(conns []Connection) {
+	sort.Slice(conns, func(i, j int) bool {
+		return lessByNameThenID(conns[i].Name, conns[i].Identifier, conns[j].Name, conns[j].Identifier)
+	})
+}
+
+// sortConnectionGroups sorts groups in place by name, then identifier.
+func sortConnectionGroups(groups []ConnectionGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		return lessByNameThenID(groups[i].Name, groups[i].Identifier, groups[j].Name, groups[j].Identifier)
+	})
+}
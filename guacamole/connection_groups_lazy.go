@@ -0,0 +1,126 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// LazyConnectionGroup is a node of the connection group hierarchy whose
+// children are fetched on demand rather than up front. It is returned by
+// GetConnectionGroupTreeLazy and is intended for tree-browsing UIs on
+// installations where fetching the full tree from ROOT is too slow to do
+// before showing anything. See LoadChildren for what each expansion costs.
+//
+// Until LoadChildren succeeds, ChildConnections and Groups are empty and
+// Loaded reports false.
+type LazyConnectionGroup struct {
+	ConnectionGroup
+
+	// Groups holds the immediate child groups once LoadChildren has been
+	// called. Each child is itself lazy and must be loaded separately.
+	Groups []*LazyConnectionGroup
+
+	client *Client
+	index  *lazyIndex
+	loaded bool
+}
+
+// lazyIndex holds the flat connection group and connection lists bucketed by
+// parent identifier. It is shared by every node of one lazy tree, so the
+// lists are fetched once rather than once per expansion.
+type lazyIndex struct {
+	mu     sync.Mutex
+	groups map[string][]ConnectionGroup
+	conns  map[string][]Connection
+}
+
+// GetConnectionGroupTreeLazy returns the connection group identified by rootID
+// without any of its descendants. Call LoadChildren on the result (and on each
+// child in turn) to expand the tree one level at a time.
+func (c *Client) GetConnectionGroupTreeLazy(ctx context.Context, rootID string) (*LazyConnectionGroup, error) {
+	group, err := c.GetConnectionGroup(ctx, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Identifier == "" {
+		group.Identifier = rootID
+	}
+	return &LazyConnectionGroup{ConnectionGroup: *group, client: c, index: &lazyIndex{}}, nil
+}
+
+// Loaded reports whether the immediate children of g have been fetched.
+func (g *LazyConnectionGroup) Loaded() bool {
+	return g.loaded
+}
+
+// LoadChildren fetches the immediate child connections and child groups of g,
+// populating ChildConnections and Groups. Results are sorted by name then
+// identifier.
+//
+// Guacamole has no single-level children endpoint, and the tree endpoint
+// returns every descendant, so the children are selected by parent identifier
+// from the flat connection group and connection lists instead. The first
+// LoadChildren anywhere in the tree makes those two list requests (without
+// parameters and without ever requesting a tree) and keeps them bucketed by
+// parent; later expansions of other nodes are answered from that copy without
+// contacting the server. Calling LoadChildren again on an already-loaded node
+// fetches the lists afresh, refreshing the copy for the whole tree.
+func (g *LazyConnectionGroup) LoadChildren(ctx context.Context) error {
+	idx := g.index
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.groups == nil || g.loaded {
+		if err := idx.refresh(ctx, g.client); err != nil {
+			return fmt.Errorf("guacamole: load children of connection group %s: %w", g.Identifier, err)
+		}
+	}
+
+	groups := idx.groups[g.Identifier]
+	childGroups := make([]*LazyConnectionGroup, 0, len(groups))
+	for _, cg := range groups {
+		childGroups = append(childGroups, &LazyConnectionGroup{ConnectionGroup: cg, client: g.client, index: idx})
+	}
+	g.Groups = childGroups
+	g.ChildConnections = append([]Connection(nil), idx.conns[g.Identifier]...)
+	g.loaded = true
+	return nil
+}
+
+// refresh replaces the buckets with freshly listed connection groups and
+// connections, each bucket sorted by name then identifier.
+func (idx *lazyIndex) refresh(ctx context.Context, c *Client) error {
+	groups, err := c.ListConnectionGroups(ctx)
+	if err != nil {
+		return err
+	}
+	conns, err := c.ListConnections(ctx)
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[string][]ConnectionGroup)
+	for id, cg := range groups {
+		if cg.Identifier == "" {
+			cg.Identifier = id
+		}
+		parent := parentOrRoot(cg.ParentIdentifier)
+		byParent[parent] = append(byParent[parent], cg)
+	}
+	for _, list := range byParent {
+		sortConnectionGroups(list)
+	}
+	connsByParent := make(map[string][]Connection)
+	for id, conn := range conns {
+		if conn.Identifier == "" {
+			conn.Identifier = id
+		}
+		parent := parentOrRoot(conn.ParentIdentifier)
+		connsByParent[parent] = append(connsByParent[parent], conn)
+	}
+	for _, list := range connsByParent {
+		sortConnections(list)
+	}
+	idx.groups, idx.conns = byParent, connsByParent
+	return nil
+}
@@ -0,0 +1,94 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetConnectionGroupTreeLazy(t *testing.T) {
+	var calls []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		calls = append(calls, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/tree") {
+			t.Errorf("lazy tree requested %s", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/connectionGroups/ROOT":
+			writeJSON(t, w, ConnectionGroup{Identifier: "ROOT", Name: "ROOT", Type: ConnectionGroupTypeOrganizational})
+		case "/api/session/data/postgresql/connectionGroups":
+			writeJSON(t, w, map[string]ConnectionGroup{
+				"1": {Identifier: "1", Name: "Servers", ParentIdentifier: "ROOT"},
+				"2": {Identifier: "2", Name: "Nested", ParentIdentifier: "1"},
+			})
+		case "/api/session/data/postgresql/connections":
+			writeJSON(t, w, map[string]Connection{
+				"5": {Identifier: "5", Name: "jumphost", ParentIdentifier: "ROOT", Protocol: "ssh"},
+				"6": {Identifier: "6", Name: "db", ParentIdentifier: "1", Protocol: "ssh"},
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	root, err := c.GetConnectionGroupTreeLazy(context.Background(), RootConnectionGroupIdentifier)
+	if err != nil {
+		t.Fatalf("GetConnectionGroupTreeLazy: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("HTTP calls before LoadChildren: got %d (%v), want 1", len(calls), calls)
+	}
+	if root.Loaded() {
+		t.Error("Loaded: got true before LoadChildren, want false")
+	}
+	if len(root.Groups) != 0 || len(root.ChildConnections) != 0 {
+		t.Error("children populated before LoadChildren")
+	}
+
+	if err := root.LoadChildren(context.Background()); err != nil {
+		t.Fatalf("LoadChildren: %v", err)
+	}
+	if !root.Loaded() {
+		t.Error("Loaded: got false after LoadChildren, want true")
+	}
+	if len(root.Groups) != 1 || root.Groups[0].Identifier != "1" {
+		t.Fatalf("Groups: got %+v, want [1]", root.Groups)
+	}
+	if len(root.ChildConnections) != 1 || root.ChildConnections[0].Identifier != "5" {
+		t.Errorf("ChildConnections: got %+v, want [5]", root.ChildConnections)
+	}
+
+	servers := root.Groups[0]
+	if servers.Loaded() {
+		t.Error("child Loaded: got true, want false")
+	}
+	if err := servers.LoadChildren(context.Background()); err != nil {
+		t.Fatalf("child LoadChildren: %v", err)
+	}
+	if len(servers.Groups) != 1 || servers.Groups[0].Identifier != "2" {
+		t.Errorf("child Groups: got %+v, want [2]", servers.Groups)
+	}
+	if len(servers.ChildConnections) != 1 || servers.ChildConnections[0].Identifier != "6" {
+		t.Errorf("child ChildConnections: got %+v, want [6]", servers.ChildConnections)
+	}
+
+	want := []string{
+		"/api/session/data/postgresql/connectionGroups/ROOT",
+		"/api/session/data/postgresql/connectionGroups",
+		"/api/session/data/postgresql/connections",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("requests after expanding two levels: got %v, want %v", calls, want)
+	}
+
+	// Reloading an expanded node refreshes the shared lists.
+	if err := root.LoadChildren(context.Background()); err != nil {
+		t.Fatalf("reload LoadChildren: %v", err)
+	}
+	if len(calls) != 5 {
+		t.Errorf("requests after reload: got %v, want the two lists fetched again", calls)
+	}
+}
@@ -2,7 +2,11 @@ package guacamole
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -106,6 +110,34 @@ func TestGetConnectionGroupTree_subtree(t *testing.T) {
 	}
 }
 
+func TestGetConnectionGroupTree_max_depth(t *testing.T) {
+	// ROOT → 1 → 2 → 3 nests three levels below ROOT.
+	tree := ConnectionGroup{Identifier: "ROOT", ChildConnectionGroups: []ConnectionGroup{
+		{Identifier: "1", ChildConnectionGroups: []ConnectionGroup{
+			{Identifier: "2", ChildConnectionGroups: []ConnectionGroup{
+				{Identifier: "3"},
+			}},
+		}},
+	}}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, tree)
+	})
+
+	WithTreeMaxDepth(3)(c)
+	if _, err := c.GetConnectionGroupTree(context.Background(), "ROOT"); err != nil {
+		t.Fatalf("depth 3 within limit 3: %v", err)
+	}
+
+	WithTreeMaxDepth(2)(c)
+	_, err := c.GetConnectionGroupTree(context.Background(), "ROOT")
+	if err == nil {
+		t.Fatal("depth 3 over limit 2: expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "maximum of 2 levels") {
+		t.Errorf("error: got %q, want it to mention the limit", err)
+	}
+}
+
 func TestUpdateConnectionGroup(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPut)
@@ -128,3 +160,551 @@ func TestDeleteConnectionGroup(t *testing.T) {
 		t.Fatalf("DeleteConnectionGroup: %v", err)
 	}
 }
+
+func TestDeleteConnectionGroup_root_guard(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	err := c.DeleteConnectionGroup(context.Background(), RootConnectionGroupIdentifier)
+	if err == nil || !strings.Contains(err.Error(), "root connection group cannot be deleted") {
+		t.Errorf("DeleteConnectionGroup(ROOT): got %v, want root guard error", err)
+	}
+}
+
+func TestUpdateConnectionGroup_self_parent_guard(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	err := c.UpdateConnectionGroup(context.Background(), "7", ConnectionGroup{Name: "loop", ParentIdentifier: "7"})
+	if err == nil || !strings.Contains(err.Error(), "own parent") {
+		t.Errorf("UpdateConnectionGroup: got %v, want self-parent error", err)
+	}
+}
+
+func TestPatchConnectionGroup_rename_preserves_attributes_and_type(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups/4")
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, ConnectionGroup{
+				Identifier:       "4",
+				Name:             "Old Name",
+				ParentIdentifier: "2",
+				Type:             ConnectionGroupTypeBalancing,
+				Attributes:       NullableStringMap{"max-connections": "10"},
+			})
+		case http.MethodPut:
+			var body ConnectionGroup
+			mustReadJSON(t, r, &body)
+			if body.Name != "New Name" {
+				t.Errorf("Name: got %q, want %q", body.Name, "New Name")
+			}
+			if body.Type != ConnectionGroupTypeBalancing {
+				t.Errorf("Type: got %q, want %q", body.Type, ConnectionGroupTypeBalancing)
+			}
+			if body.ParentIdentifier != "2" {
+				t.Errorf("ParentIdentifier: got %q, want %q", body.ParentIdentifier, "2")
+			}
+			if body.Attributes["max-connections"] != "10" {
+				t.Errorf(`Attributes["max-connections"]: got %q, want "10"`, body.Attributes["max-connections"])
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	})
+	err := c.PatchConnectionGroup(context.Background(), "4", func(g *ConnectionGroup) {
+		g.Name = "New Name"
+	})
+	if err != nil {
+		t.Fatalf("PatchConnectionGroup: %v", err)
+	}
+}
+
+func TestPatchConnectionGroup_null_attributes_sent_as_object(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"identifier":"4","name":"g","type":"ORGANIZATIONAL","attributes":null}`))
+		case http.MethodPut:
+			var raw map[string]json.RawMessage
+			mustReadJSON(t, r, &raw)
+			if string(raw["attributes"]) != `{"note":"x"}` {
+				t.Errorf("attributes: got %s, want {\"note\":\"x\"}", raw["attributes"])
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	err := c.PatchConnectionGroup(context.Background(), "4", func(g *ConnectionGroup) {
+		g.Attributes["note"] = "x"
+	})
+	if err != nil {
+		t.Fatalf("PatchConnectionGroup: %v", err)
+	}
+}
+
+// moveTree is the subtree served for group 4 by the MoveConnectionGroup
+// tests: 4 contains 5, which contains 6.
+var moveTree = ConnectionGroup{
+	Identifier: "4",
+	Name:       "Lab",
+	ChildConnectionGroups: []ConnectionGroup{{
+		Identifier:            "5",
+		Name:                  "Pool",
+		ChildConnectionGroups: []ConnectionGroup{{Identifier: "6", Name: "Inner"}},
+	}},
+}
+
+func TestMoveConnectionGroup(t *testing.T) {
+	puts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connectionGroups/4/tree":
+			writeJSON(t, w, moveTree)
+		case r.Method == http.MethodGet:
+			assertPath(t, r, "/api/session/data/postgresql/connectionGroups/4")
+			writeJSON(t, w, ConnectionGroup{
+				Identifier:       "4",
+				Name:             "Lab",
+				ParentIdentifier: "2",
+				Type:             ConnectionGroupTypeBalancing,
+				Attributes:       NullableStringMap{"max-connections": "10"},
+			})
+		case r.Method == http.MethodPut:
+			puts++
+			var body ConnectionGroup
+			mustReadJSON(t, r, &body)
+			if body.ParentIdentifier != "9" {
+				t.Errorf("ParentIdentifier: got %q, want %q", body.ParentIdentifier, "9")
+			}
+			if body.Name != "Lab" || body.Type != ConnectionGroupTypeBalancing || body.Attributes["max-connections"] != "10" {
+				t.Errorf("group not preserved: %+v", body)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	if err := c.MoveConnectionGroup(context.Background(), "4", "9"); err != nil {
+		t.Fatalf("MoveConnectionGroup: %v", err)
+	}
+	if puts != 1 {
+		t.Errorf("PUT requests: got %d, want 1", puts)
+	}
+}
+
+func TestMoveConnectionGroup_rejects_cycle(t *testing.T) {
+	for _, parent := range []string{"4", "5", "6"} {
+		t.Run(parent, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/api/session/data/postgresql/connectionGroups/4/tree" {
+					t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+				}
+				writeJSON(t, w, moveTree)
+			})
+			if err := c.MoveConnectionGroup(context.Background(), "4", parent); err == nil {
+				t.Errorf("expected error moving 4 under %s, got nil", parent)
+			}
+		})
+	}
+}
+
+func TestListConnectionGroupChildren(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups/7/tree")
+		writeJSON(t, w, ConnectionGroup{
+			Identifier: "7",
+			Name:       "DC East",
+			ChildConnections: []Connection{
+				{Identifier: "12", Name: "web", Protocol: "ssh"},
+				{Identifier: "11", Name: "db", Protocol: "ssh", ParentIdentifier: "7"},
+			},
+			ChildConnectionGroups: []ConnectionGroup{
+				{
+					Identifier: "9", Name: "Rack B", Type: ConnectionGroupTypeBalancing,
+					ChildConnections: []Connection{{Identifier: "20", Name: "node"}},
+				},
+				{Identifier: "8", Name: "Rack A", Type: ConnectionGroupTypeOrganizational},
+			},
+		})
+	})
+	conns, groups, err := c.ListConnectionGroupChildren(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("ListConnectionGroupChildren: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("HTTP calls: got %d, want 1", calls)
+	}
+	if len(conns) != 2 || conns[0].Identifier != "11" || conns[1].Identifier != "12" {
+		t.Fatalf("connections: got %+v, want [11 12] sorted by name", conns)
+	}
+	if conns[1].ParentIdentifier != "7" {
+		t.Errorf("ParentIdentifier: got %q, want %q", conns[1].ParentIdentifier, "7")
+	}
+	if len(groups) != 2 || groups[0].Identifier != "8" || groups[1].Identifier != "9" {
+		t.Fatalf("groups: got %+v, want [8 9] sorted by name", groups)
+	}
+	if groups[1].ChildConnections != nil {
+		t.Errorf("grandchildren: got %+v, want none", groups[1].ChildConnections)
+	}
+}
+
+func TestConnectionGroup_type_predicates(t *testing.T) {
+	b := ConnectionGroup{Type: ConnectionGroupTypeBalancing}
+	o := ConnectionGroup{Type: ConnectionGroupTypeOrganizational}
+	if !b.IsBalancing() || b.IsOrganizational() {
+		t.Errorf("balancing group: IsBalancing=%v IsOrganizational=%v", b.IsBalancing(), b.IsOrganizational())
+	}
+	if o.IsBalancing() || !o.IsOrganizational() {
+		t.Errorf("organizational group: IsBalancing=%v IsOrganizational=%v", o.IsBalancing(), o.IsOrganizational())
+	}
+}
+
+func TestConnectionGroup_WalkWithOptions(t *testing.T) {
+	tree := ConnectionGroup{
+		Identifier: "ROOT",
+		Type:       ConnectionGroupTypeOrganizational,
+		ChildConnections: []Connection{
+			{Identifier: "1"},
+		},
+		ChildConnectionGroups: []ConnectionGroup{
+			{
+				Identifier: "pool",
+				Type:       ConnectionGroupTypeBalancing,
+				ChildConnections: []Connection{
+					{Identifier: "2"},
+					{Identifier: "3"},
+				},
+			},
+			{
+				Identifier: "folder",
+				Type:       ConnectionGroupTypeOrganizational,
+				ChildConnections: []Connection{
+					{Identifier: "4"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name       string
+		opts       WalkOptions
+		wantGroups string
+		wantConns  string
+	}{
+		{"default", WalkOptions{}, "ROOT,pool,folder", "1,2,3,4"},
+		{"skip balancing", WalkOptions{SkipBalancing: true}, "ROOT,pool,folder", "1,4"},
+		{"flatten balancing", WalkOptions{FlattenBalancing: true}, "ROOT,folder", "1,2,3,4"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var groups, conns []string
+			tree.WalkWithOptions(tc.opts,
+				func(g *ConnectionGroup) bool {
+					groups = append(groups, g.Identifier)
+					return true
+				},
+				func(c *Connection) {
+					conns = append(conns, c.Identifier)
+				},
+			)
+			if got := strings.Join(groups, ","); got != tc.wantGroups {
+				t.Errorf("groups: got %s, want %s", got, tc.wantGroups)
+			}
+			if got := strings.Join(conns, ","); got != tc.wantConns {
+				t.Errorf("connections: got %s, want %s", got, tc.wantConns)
+			}
+		})
+	}
+}
+
+func TestResolveConnectionGroupPath(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups")
+		writeJSON(t, w, map[string]ConnectionGroup{
+			"1": {Identifier: "1", Name: "Labs", ParentIdentifier: "ROOT"},
+			"2": {Identifier: "2", Name: "Team", ParentIdentifier: "1"},
+			"3": {Identifier: "3", Name: "Team", ParentIdentifier: "1"},
+		})
+	})
+	cases := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"", RootConnectionGroupIdentifier, false},
+		{"/", RootConnectionGroupIdentifier, false},
+		{"Labs", "1", false},
+		{"Labs/Team", "", true},
+		{"Nope", "", true},
+	}
+	for _, tc := range cases {
+		got, err := c.ResolveConnectionGroupPath(context.Background(), tc.path)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ResolveConnectionGroupPath(%q): err=%v, wantErr=%v", tc.path, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ResolveConnectionGroupPath(%q): got %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestCloneConnectionGroup(t *testing.T) {
+	source := ConnectionGroup{
+		Identifier: "10",
+		Name:       "Lab",
+		Type:       ConnectionGroupTypeOrganizational,
+		Attributes: NullableStringMap{"max-connections": "4"},
+		ChildConnections: []Connection{
+			{Identifier: "100", Name: "gateway", Protocol: "ssh", Attributes: NullableStringMap{"weight": "1"}},
+		},
+		ChildConnectionGroups: []ConnectionGroup{{
+			Identifier: "11",
+			Name:       "Pool",
+			Type:       ConnectionGroupTypeBalancing,
+			ChildConnections: []Connection{
+				{Identifier: "101", Name: "desktop", Protocol: "rdp"},
+			},
+		}},
+	}
+	params := map[string]map[string]string{
+		"100": {"hostname": "gw.lab"},
+		"101": {"hostname": "desk.lab", "port": "3389"},
+	}
+
+	var groups []ConnectionGroup
+	var conns []Connection
+	nextID := 200
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		base := "/api/session/data/postgresql/"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"connectionGroups/10/tree":
+			writeJSON(t, w, source)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/parameters"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, base+"connections/"), "/parameters")
+			writeJSON(t, w, params[id])
+		case r.Method == http.MethodPost && r.URL.Path == base+"connectionGroups":
+			var g ConnectionGroup
+			mustReadJSON(t, r, &g)
+			nextID++
+			g.Identifier = strconv.Itoa(nextID)
+			groups = append(groups, g)
+			writeJSON(t, w, g)
+		case r.Method == http.MethodPost && r.URL.Path == base+"connections":
+			var conn Connection
+			mustReadJSON(t, r, &conn)
+			nextID++
+			conn.Identifier = strconv.Itoa(nextID)
+			conns = append(conns, conn)
+			writeJSON(t, w, conn)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	root, err := c.CloneConnectionGroup(context.Background(), "10", "5", "Lab copy")
+	if err != nil {
+		t.Fatalf("CloneConnectionGroup: %v", err)
+	}
+	if root.Identifier != "201" || root.Name != "Lab copy" {
+		t.Errorf("root: got %s %q, want 201 %q", root.Identifier, root.Name, "Lab copy")
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("groups created: got %d, want 2", len(groups))
+	}
+	if g := groups[0]; g.ParentIdentifier != "5" || g.Type != ConnectionGroupTypeOrganizational || g.Attributes["max-connections"] != "4" {
+		t.Errorf("root group: got %+v", g)
+	}
+	if g := groups[1]; g.Name != "Pool" || g.ParentIdentifier != "201" || g.Type != ConnectionGroupTypeBalancing {
+		t.Errorf("child group: got %+v", g)
+	}
+
+	if len(conns) != 2 {
+		t.Fatalf("connections created: got %d, want 2", len(conns))
+	}
+	if conn := conns[0]; conn.Name != "gateway" || conn.ParentIdentifier != "201" ||
+		conn.Parameters["hostname"] != "gw.lab" || conn.Attributes["weight"] != "1" {
+		t.Errorf("root connection: got %+v", conn)
+	}
+	if conn := conns[1]; conn.Name != "desktop" || conn.ParentIdentifier != groups[1].Identifier ||
+		conn.Protocol != "rdp" || conn.Parameters["port"] != "3389" {
+		t.Errorf("child connection: got %+v", conn)
+	}
+}
+
+func TestCloneConnectionGroup_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	posts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		writeJSON(t, w, ConnectionGroup{Identifier: "10", Name: "Lab"})
+		cancel()
+	})
+	if _, err := c.CloneConnectionGroup(ctx, "10", "ROOT", "copy"); err == nil {
+		t.Fatal("expected error after cancellation, got nil")
+	}
+	if posts != 0 {
+		t.Errorf("POST requests after cancellation: got %d, want 0", posts)
+	}
+}
+
+func TestCloneConnectionGroup_partial_failure_returns_root(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		base := "/api/session/data/postgresql/"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"connectionGroups/10/tree":
+			writeJSON(t, w, ConnectionGroup{
+				Identifier:       "10",
+				Name:             "Lab",
+				ChildConnections: []Connection{{Identifier: "100", Name: "gateway", Protocol: "ssh"}},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == base+"connectionGroups":
+			writeJSON(t, w, ConnectionGroup{Identifier: "201", Name: "Lab copy"})
+		case r.Method == http.MethodGet && r.URL.Path == base+"connections/100/parameters":
+			writeAPIError(t, w, http.StatusForbidden, "PERMISSION_DENIED", "Permission denied.")
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	root, err := c.CloneConnectionGroup(context.Background(), "10", "ROOT", "Lab copy")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if root == nil || root.Identifier != "201" {
+		t.Errorf("root: got %+v, want the created group 201", root)
+	}
+}
+
+func TestGetConnectionGroupStats(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups/7/tree")
+		writeJSON(t, w, ConnectionGroup{
+			Identifier: "7",
+			ChildConnections: []Connection{
+				{Identifier: "1", ActiveConnections: 2},
+				{Identifier: "2"},
+			},
+			ChildConnectionGroups: []ConnectionGroup{
+				{Identifier: "8", ChildConnections: []Connection{{Identifier: "3", ActiveConnections: 1}}},
+				{Identifier: "9", ChildConnectionGroups: []ConnectionGroup{
+					{Identifier: "10", ChildConnections: []Connection{{Identifier: "4", ActiveConnections: 4}}},
+				}},
+			},
+		})
+	})
+	got, err := c.GetConnectionGroupStats(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("GetConnectionGroupStats: %v", err)
+	}
+	want := GroupStats{Groups: 3, Connections: 4, ActiveConnections: 7}
+	if *got != want {
+		t.Errorf("stats: got %+v, want %+v", *got, want)
+	}
+}
+
+func TestRenameConnectionGroup_changes_only_name(t *testing.T) {
+	current := ConnectionGroup{
+		Identifier:       "4",
+		Name:             "Old Name",
+		ParentIdentifier: "2",
+		Type:             ConnectionGroupTypeBalancing,
+		Attributes:       NullableStringMap{"max-connections": "10", "enable-session-affinity": "true"},
+	}
+	var put ConnectionGroup
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups/4")
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, current)
+		case http.MethodPut:
+			mustReadJSON(t, r, &put)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	})
+	if err := c.RenameConnectionGroup(context.Background(), "4", "New Name"); err != nil {
+		t.Fatalf("RenameConnectionGroup: %v", err)
+	}
+	want := current
+	want.Name = "New Name"
+	if !reflect.DeepEqual(put, want) {
+		t.Errorf("PUT body: got %+v, want %+v", put, want)
+	}
+}
+
+func TestPatchConnectionGroup_error_not_rewrapped(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "No such connection group.")
+	})
+	err := c.PatchConnectionGroup(context.Background(), "4", func(*ConnectionGroup) {})
+	if !IsNotFound(err) {
+		t.Fatalf("IsNotFound: got false, want true (err=%v)", err)
+	}
+	if n := strings.Count(err.Error(), "guacamole:"); n != 1 {
+		t.Errorf("error %q: got %d \"guacamole:\" prefixes, want 1", err, n)
+	}
+}
+
+// TestReadModifyWrite_error_prefixes pins the wrapping policy of the
+// read-modify-write helpers: the read and write primitives
+// (PatchConnectionGroup, modifyConnection, modifySharingProfile) add no
+// prefix of their own, so an error carries exactly the helper's operation
+// followed by that of the request that failed.
+func TestReadModifyWrite_error_prefixes(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodGet:
+			writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission Denied.")
+		case strings.HasSuffix(r.URL.Path, "/parameters"):
+			writeJSON(t, w, map[string]string{})
+		case strings.HasSuffix(r.URL.Path, "/tree"):
+			writeJSON(t, w, moveTree)
+		default:
+			writeJSON(t, w, ConnectionGroup{Identifier: "4", Name: "Lab", Type: ConnectionGroupTypeOrganizational})
+		}
+	})
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		call func() error
+		want string
+	}{
+		{"PatchConnectionGroup", func() error { return c.PatchConnectionGroup(ctx, "4", func(*ConnectionGroup) {}) },
+			"guacamole: update connection group 4: "},
+		{"MoveConnectionGroup", func() error { return c.MoveConnectionGroup(ctx, "4", "9") },
+			"guacamole: move connection group 4: guacamole: update connection group 4: "},
+		{"RenameConnectionGroup", func() error { return c.RenameConnectionGroup(ctx, "4", "New") },
+			"guacamole: rename connection group 4: guacamole: update connection group 4: "},
+		{"RenameConnection", func() error { return c.RenameConnection(ctx, "4", "New") },
+			"guacamole: rename connection 4: guacamole: update connection 4: "},
+		{"UpdateConnectionAttributes", func() error { return c.UpdateConnectionAttributes(ctx, "4", NullableStringMap{"a": "b"}) },
+			"guacamole: update connection attributes 4: guacamole: update connection 4: "},
+		{"MoveConnectionToRoot", func() error { return c.MoveConnectionToRoot(ctx, "4") },
+			"guacamole: move connection 4 to root: guacamole: update connection 4: "},
+		{"RenameSharingProfile", func() error { return c.RenameSharingProfile(ctx, "4", "New") },
+			"guacamole: rename sharing profile 4: guacamole: update sharing profile 4: "},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			if !IsPermissionDenied(err) {
+				t.Fatalf("IsPermissionDenied: got false, want true (err=%v)", err)
+			}
+			msg := err.Error()
+			if !strings.HasPrefix(msg, tc.want) {
+				t.Errorf("error: got %q, want prefix %q", msg, tc.want)
+			}
+			if got, want := strings.Count(msg, "guacamole:"), strings.Count(tc.want, "guacamole:"); got != want {
+				t.Errorf("error %q: got %d \"guacamole:\" prefixes, want %d", msg, got, want)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"net"
+	"sort"
+	"strings"
 )
 
 // ListConnections returns all connections visible to the authenticated user,
@@ -15,6 +18,76 @@ func (c *Client) ListConnections(ctx context.Context) (map[string]Connection, er
 	return result, nil
 }
 
+// ListConnectionsByProtocol returns the connections whose protocol matches
+// protocol case-insensitively (e.g. "rdp" matches "RDP"), keyed by connection
+// identifier. The returned map is empty, never nil, when nothing matches.
+func (c *Client) ListConnectionsByProtocol(ctx context.Context, protocol string) (map[string]Connection, error) {
+	conns, err := c.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]Connection)
+	for id, conn := range conns {
+		if strings.EqualFold(conn.Protocol, protocol) {
+			result[id] = conn
+		}
+	}
+	return result, nil
+}
+
+// ListConnectionsByGroup returns all connections bucketed by the identifier
+// of their parent connection group, with top-level connections under
+// RootConnectionGroupIdentifier. Each bucket is sorted by name, then
+// identifier. Unlike GetConnectionGroupTree, only one flat list is fetched.
+func (c *Client) ListConnectionsByGroup(ctx context.Context) (map[string][]Connection, error) {
+	conns, err := c.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]Connection)
+	for id, conn := range conns {
+		if conn.Identifier == "" {
+			conn.Identifier = id
+		}
+		parent := parentOrRoot(conn.ParentIdentifier)
+		result[parent] = append(result[parent], conn)
+	}
+	for _, bucket := range result {
+		sortConnections(bucket)
+	}
+	return result, nil
+}
+
+// FindDuplicateConnectionNames reports connections that share a name with a
+// sibling, which Guacamole permits but which makes name-based lookups
+// ambiguous. The result is keyed by "parent/name", where parent is the
+// parent group identifier (RootConnectionGroupIdentifier for top-level
+// connections), and lists the colliding identifiers in sorted order. Names
+// that are unique within their group are omitted, so the map is empty, never
+// nil, when there are no duplicates.
+func (c *Client) FindDuplicateConnectionNames(ctx context.Context) (map[string][]string, error) {
+	conns, err := c.ListConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string)
+	for id, conn := range conns {
+		if conn.Identifier == "" {
+			conn.Identifier = id
+		}
+		key := parentOrRoot(conn.ParentIdentifier) + "/" + conn.Name
+		byName[key] = append(byName[key], conn.Identifier)
+	}
+	result := make(map[string][]string)
+	for key, ids := range byName {
+		if len(ids) > 1 {
+			sort.Strings(ids)
+			result[key] = ids
+		}
+	}
+	return result, nil
+}
+
 // CreateConnection creates a new connection and returns the created resource
 // with its server-assigned identifier.
 func (c *Client) CreateConnection(ctx context.Context, conn Connection) (*Connection, error) {
@@ -25,6 +98,19 @@ func (c *Client) CreateConnection(ctx context.Context, conn Connection) (*Connec
 	return &result, nil
 }
 
+// CreateConnectionUnderPath creates conn inside the connection group reached
+// by groupPath (see ResolveConnectionGroupPath), overriding
+// conn.ParentIdentifier. When createMissing is true, any missing groups along
+// the path are created as organizational groups first.
+func (c *Client) CreateConnectionUnderPath(ctx context.Context, groupPath string, conn Connection, createMissing bool) (*Connection, error) {
+	parentID, err := c.resolveConnectionGroupPath(ctx, groupPath, createMissing)
+	if err != nil {
+		return nil, err
+	}
+	conn.ParentIdentifier = parentID
+	return c.CreateConnection(ctx, conn)
+}
+
 // GetConnection retrieves the connection with the given identifier.
 // Note: the returned Connection does not include protocol parameters; call
 // GetConnectionParameters separately to obtain those.
@@ -37,15 +123,31 @@ func (c *Client) GetConnection(ctx context.Context, id string) (*Connection, err
 }
 
 // GetConnectionParameters returns the protocol-specific parameters for the
-// connection with the given identifier (e.g. hostname, port, username).
+// connection with the given identifier (e.g. hostname, port, username). The
+// map is never nil, so callers may add to it directly. Secrets such as
+// passwords and private keys are returned in plaintext; use
+// GetConnectionParametersMasked when the result is only to be shown or logged.
 func (c *Client) GetConnectionParameters(ctx context.Context, id string) (map[string]string, error) {
 	var result map[string]string
 	if err := c.get(ctx, c.dataPath("connections", id, "parameters"), &result); err != nil {
 		return nil, fmt.Errorf("guacamole: get connection parameters %s: %w", id, err)
 	}
+	if result == nil {
+		result = map[string]string{}
+	}
 	return result, nil
 }
 
+// GetConnectionParametersMasked is GetConnectionParameters with secret values
+// replaced by "***" (see MaskSecrets), for logging and read-only display.
+func (c *Client) GetConnectionParametersMasked(ctx context.Context, id string) (map[string]string, error) {
+	params, err := c.GetConnectionParameters(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return MaskSecrets(params), nil
+}
+
 // UpdateConnection replaces the connection identified by id with the supplied
 // Connection. The identifier field within conn is ignored; id is used.
 func (c *Client) UpdateConnection(ctx context.Context, id string, conn Connection) error {
@@ -55,6 +157,69 @@ func (c *Client) UpdateConnection(ctx context.Context, id string, conn Connectio
 	return nil
 }
 
+// UpdateConnectionAttributes merges attrs into the attributes of the
+// connection identified by id, leaving its other attributes and all of its
+// parameters unchanged.
+func (c *Client) UpdateConnectionAttributes(ctx context.Context, id string, attrs NullableStringMap) error {
+	err := c.modifyConnection(ctx, id, func(conn *Connection) {
+		for k, v := range attrs {
+			conn.Attributes[k] = v
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("guacamole: update connection attributes %s: %w", id, err)
+	}
+	return nil
+}
+
+// MoveConnectionToRoot moves the connection identified by id to the top
+// level of the connection hierarchy, preserving its parameters and
+// attributes. It sends ParentIdentifier as RootConnectionGroupIdentifier
+// explicitly, since an empty ParentIdentifier is dropped from the request.
+func (c *Client) MoveConnectionToRoot(ctx context.Context, id string) error {
+	err := c.modifyConnection(ctx, id, func(conn *Connection) {
+		conn.ParentIdentifier = RootConnectionGroupIdentifier
+	})
+	if err != nil {
+		return fmt.Errorf("guacamole: move connection %s to root: %w", id, err)
+	}
+	return nil
+}
+
+// RenameConnection changes the name of the connection identified by id,
+// preserving its protocol, parent, parameters and attributes.
+func (c *Client) RenameConnection(ctx context.Context, id, newName string) error {
+	err := c.modifyConnection(ctx, id, func(conn *Connection) {
+		conn.Name = newName
+	})
+	if err != nil {
+		return fmt.Errorf("guacamole: rename connection %s: %w", id, err)
+	}
+	return nil
+}
+
+// modifyConnection fetches the connection identified by id together with its
+// parameters, applies fn, and PUTs the complete object back, since
+// UpdateConnection replaces whatever is not re-sent. The Attributes map is
+// never nil when fn is called. Errors are returned as-is, already naming the
+// request that failed; callers add only their own operation.
+func (c *Client) modifyConnection(ctx context.Context, id string, fn func(*Connection)) error {
+	conn, err := c.GetConnection(ctx, id)
+	if err != nil {
+		return err
+	}
+	params, err := c.GetConnectionParameters(ctx, id)
+	if err != nil {
+		return err
+	}
+	if conn.Attributes == nil {
+		conn.Attributes = NullableStringMap{}
+	}
+	conn.Parameters = params
+	fn(conn)
+	return c.UpdateConnection(ctx, id, *conn)
+}
+
 // DeleteConnection permanently removes the connection with the given
 // identifier.
 func (c *Client) DeleteConnection(ctx context.Context, id string) error {
@@ -63,3 +228,40 @@ func (c *Client) DeleteConnection(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// defaultProtocolPorts holds the port guacd uses for each protocol when a
+// connection has no port parameter.
+var defaultProtocolPorts = map[string]string{
+	"ssh":        "22",
+	"telnet":     "23",
+	"rdp":        "3389",
+	"vnc":        "5900",
+	"kubernetes": "8080",
+}
+
+// TestConnectionReachability opens a TCP connection to the hostname and port
+// parameters of conn, falling back to the protocol's default port, and closes
+// it immediately. The dial is made from the caller's machine, not from guacd,
+// and is bounded by ctx. A nil error only means the port accepted a TCP
+// connection; it says nothing about whether the protocol, credentials or
+// other parameters are correct.
+func (c *Client) TestConnectionReachability(ctx context.Context, conn Connection) error {
+	host := conn.Parameters["hostname"]
+	if host == "" {
+		return fmt.Errorf("guacamole: test reachability of %q: no hostname parameter", conn.Name)
+	}
+	port := conn.Parameters["port"]
+	if port == "" {
+		port = defaultProtocolPorts[strings.ToLower(conn.Protocol)]
+	}
+	if port == "" {
+		return fmt.Errorf("guacamole: test reachability of %q: no port parameter and no default for protocol %q", conn.Name, conn.Protocol)
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("guacamole: test reachability of %q: %w", conn.Name, err)
+	}
+	return nc.Close()
+}
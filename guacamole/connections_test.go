@@ -3,7 +3,12 @@ package guacamole
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -29,6 +34,95 @@ func TestListConnections(t *testing.T) {
 	}
 }
 
+func TestListConnectionsByProtocol(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connections")
+		writeJSON(t, w, map[string]Connection{
+			"1": {Identifier: "1", Name: "a", Protocol: "rdp"},
+			"2": {Identifier: "2", Name: "b", Protocol: "RDP"},
+			"3": {Identifier: "3", Name: "c", Protocol: "ssh"},
+			"4": {Identifier: "4", Name: "d", Protocol: "vnc"},
+		})
+	})
+
+	got, err := c.ListConnectionsByProtocol(context.Background(), "Rdp")
+	if err != nil {
+		t.Fatalf("ListConnectionsByProtocol: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("len: got %d, want 2", len(got))
+	}
+	for _, id := range []string{"1", "2"} {
+		if _, ok := got[id]; !ok {
+			t.Errorf("missing connection %s", id)
+		}
+	}
+
+	none, err := c.ListConnectionsByProtocol(context.Background(), "telnet")
+	if err != nil {
+		t.Fatalf("ListConnectionsByProtocol(telnet): %v", err)
+	}
+	if none == nil {
+		t.Error("got nil map, want empty non-nil map")
+	}
+	if len(none) != 0 {
+		t.Errorf("len: got %d, want 0", len(none))
+	}
+}
+
+func TestListConnectionsByGroup(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connections")
+		writeJSON(t, w, map[string]Connection{
+			"1": {Name: "web", ParentIdentifier: "ROOT"},
+			"2": {Name: "db", ParentIdentifier: ""},
+			"3": {Name: "b-node", ParentIdentifier: "7"},
+			"4": {Name: "a-node", ParentIdentifier: "7"},
+			"5": {Name: "lab", ParentIdentifier: "9"},
+		})
+	})
+	got, err := c.ListConnectionsByGroup(context.Background())
+	if err != nil {
+		t.Fatalf("ListConnectionsByGroup: %v", err)
+	}
+	ids := func(conns []Connection) string {
+		var out []string
+		for _, conn := range conns {
+			out = append(out, conn.Identifier)
+		}
+		return strings.Join(out, ",")
+	}
+	want := map[string]string{"ROOT": "2,1", "7": "4,3", "9": "5"}
+	if len(got) != len(want) {
+		t.Errorf("buckets: got %d, want %d", len(got), len(want))
+	}
+	for parent, wantIDs := range want {
+		if g := ids(got[parent]); g != wantIDs {
+			t.Errorf("bucket %s: got %s, want %s", parent, g, wantIDs)
+		}
+	}
+}
+
+func TestFindDuplicateConnectionNames(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connections")
+		writeJSON(t, w, map[string]Connection{
+			"3": {Name: "web", ParentIdentifier: "7"},
+			"1": {Name: "web", ParentIdentifier: "7"},
+			"2": {Name: "web", ParentIdentifier: "ROOT"},
+			"4": {Name: "db", ParentIdentifier: "7"},
+		})
+	})
+	got, err := c.FindDuplicateConnectionNames(context.Background())
+	if err != nil {
+		t.Fatalf("FindDuplicateConnectionNames: %v", err)
+	}
+	want := map[string][]string{"7/web": {"1", "3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicates: got %v, want %v", got, want)
+	}
+}
+
 func TestCreateConnection(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPost)
@@ -67,6 +161,20 @@ func TestCreateConnection(t *testing.T) {
 	}
 }
 
+func TestCreateConnection_empty_success_body(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPost)
+		w.WriteHeader(http.StatusOK)
+	})
+	got, err := c.CreateConnection(context.Background(), Connection{Name: "x", Protocol: "ssh"})
+	if err != nil {
+		t.Fatalf("CreateConnection with empty 200 body: %v", err)
+	}
+	if got == nil || got.Identifier != "" {
+		t.Errorf("result: got %+v, want empty connection", got)
+	}
+}
+
 func TestCreateConnection_nil_attributes_serialized_as_empty_object(t *testing.T) {
 	// Regression test: nil Attributes must marshal as {} not be omitted.
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
@@ -124,6 +232,26 @@ func TestGetConnectionParameters(t *testing.T) {
 	}
 }
 
+func TestGetConnectionParameters_empty_is_writable(t *testing.T) {
+	for _, body := range []string{"null", "{}"} {
+		t.Run(body, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertPath(t, r, "/api/session/data/postgresql/connections/7/parameters")
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, body)
+			})
+			got, err := c.GetConnectionParameters(context.Background(), "7")
+			if err != nil {
+				t.Fatalf("GetConnectionParameters: %v", err)
+			}
+			if got == nil {
+				t.Fatal("parameters: got nil map, want empty map")
+			}
+			got["hostname"] = "added" // must not panic
+		})
+	}
+}
+
 func TestUpdateConnection(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPut)
@@ -141,6 +269,78 @@ func TestUpdateConnection(t *testing.T) {
 	}
 }
 
+func TestUpdateConnectionAttributes_preserves_parameters(t *testing.T) {
+	var put Connection
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42":
+			writeJSON(t, w, Connection{
+				Identifier:       "42",
+				Name:             "db",
+				ParentIdentifier: "ROOT",
+				Protocol:         "ssh",
+				Attributes:       NullableStringMap{"max-connections": "1", "weight": "5"},
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42/parameters":
+			writeJSON(t, w, map[string]string{"hostname": "db.internal", "port": "22"})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/session/data/postgresql/connections/42":
+			mustReadJSON(t, r, &put)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	err := c.UpdateConnectionAttributes(context.Background(), "42", NullableStringMap{"max-connections": "10"})
+	if err != nil {
+		t.Fatalf("UpdateConnectionAttributes: %v", err)
+	}
+	if put.Parameters["hostname"] != "db.internal" || put.Parameters["port"] != "22" {
+		t.Errorf("parameters: got %v, want preserved", put.Parameters)
+	}
+	if put.Attributes["max-connections"] != "10" {
+		t.Errorf("max-connections: got %q, want %q", put.Attributes["max-connections"], "10")
+	}
+	if put.Attributes["weight"] != "5" {
+		t.Errorf("weight: got %q, want preserved %q", put.Attributes["weight"], "5")
+	}
+	if put.Name != "db" || put.Protocol != "ssh" || put.ParentIdentifier != "ROOT" {
+		t.Errorf("connection fields: got %+v", put)
+	}
+}
+
+func TestMoveConnectionToRoot(t *testing.T) {
+	var body map[string]json.RawMessage
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42":
+			writeJSON(t, w, Connection{Identifier: "42", Name: "db", ParentIdentifier: "7", Protocol: "ssh",
+				Attributes: NullableStringMap{"max-connections": "2"}})
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42/parameters":
+			writeJSON(t, w, map[string]string{"hostname": "db.internal"})
+		case r.Method == http.MethodPut:
+			mustReadJSON(t, r, &body)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	if err := c.MoveConnectionToRoot(context.Background(), "42"); err != nil {
+		t.Fatalf("MoveConnectionToRoot: %v", err)
+	}
+	if got := string(body["parentIdentifier"]); got != `"ROOT"` {
+		t.Errorf("parentIdentifier: got %s, want %q", got, "ROOT")
+	}
+	var params map[string]string
+	if err := json.Unmarshal(body["parameters"], &params); err != nil || params["hostname"] != "db.internal" {
+		t.Errorf("parameters: got %s, want preserved", body["parameters"])
+	}
+	var attrs map[string]string
+	if err := json.Unmarshal(body["attributes"], &attrs); err != nil || attrs["max-connections"] != "2" {
+		t.Errorf("attributes: got %s, want preserved", body["attributes"])
+	}
+}
+
 func TestDeleteConnection(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodDelete)
@@ -151,3 +351,152 @@ func TestDeleteConnection(t *testing.T) {
 		t.Fatalf("DeleteConnection: %v", err)
 	}
 }
+
+// pathFixtureGroups is a small hierarchy: ROOT → Data Centers(1) → East(2).
+var pathFixtureGroups = map[string]ConnectionGroup{
+	"1": {Identifier: "1", Name: "Data Centers", ParentIdentifier: "ROOT", Type: ConnectionGroupTypeOrganizational},
+	"2": {Identifier: "2", Name: "East", ParentIdentifier: "1", Type: ConnectionGroupTypeOrganizational},
+}
+
+func TestCreateConnectionUnderPath_existing(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connectionGroups":
+			writeJSON(t, w, pathFixtureGroups)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/session/data/postgresql/connections":
+			var body Connection
+			mustReadJSON(t, r, &body)
+			if body.ParentIdentifier != "2" {
+				t.Errorf("ParentIdentifier: got %q, want %q", body.ParentIdentifier, "2")
+			}
+			body.Identifier = "50"
+			writeJSON(t, w, body)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	conn, err := c.CreateConnectionUnderPath(context.Background(), "Data Centers/East",
+		Connection{Name: "web", Protocol: "ssh"}, false)
+	if err != nil {
+		t.Fatalf("CreateConnectionUnderPath: %v", err)
+	}
+	if conn.Identifier != "50" {
+		t.Errorf("Identifier: got %q, want %q", conn.Identifier, "50")
+	}
+}
+
+func TestCreateConnectionUnderPath_missing_without_create(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, pathFixtureGroups)
+	})
+	_, err := c.CreateConnectionUnderPath(context.Background(), "Data Centers/West",
+		Connection{Name: "web", Protocol: "ssh"}, false)
+	if err == nil {
+		t.Fatal("expected error for missing group, got nil")
+	}
+}
+
+func TestCreateConnectionUnderPath_creates_missing_groups(t *testing.T) {
+	var created []ConnectionGroup
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			writeJSON(t, w, pathFixtureGroups)
+		case r.URL.Path == "/api/session/data/postgresql/connectionGroups":
+			var body ConnectionGroup
+			mustReadJSON(t, r, &body)
+			created = append(created, body)
+			body.Identifier = fmt.Sprintf("%d", 10+len(created))
+			writeJSON(t, w, body)
+		case r.URL.Path == "/api/session/data/postgresql/connections":
+			var body Connection
+			mustReadJSON(t, r, &body)
+			if body.ParentIdentifier != "12" {
+				t.Errorf("ParentIdentifier: got %q, want %q", body.ParentIdentifier, "12")
+			}
+			writeJSON(t, w, body)
+		}
+	})
+	_, err := c.CreateConnectionUnderPath(context.Background(), "/Data Centers/West/Rack 1/",
+		Connection{Name: "web", Protocol: "ssh"}, true)
+	if err != nil {
+		t.Fatalf("CreateConnectionUnderPath: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("created groups: got %d, want 2", len(created))
+	}
+	if created[0].Name != "West" || created[0].ParentIdentifier != "1" {
+		t.Errorf("first group: got %+v, want West under 1", created[0])
+	}
+	if created[1].Name != "Rack 1" || created[1].ParentIdentifier != "11" {
+		t.Errorf("second group: got %+v, want Rack 1 under 11", created[1])
+	}
+	if created[1].Type != ConnectionGroupTypeOrganizational {
+		t.Errorf("Type: got %q, want ORGANIZATIONAL", created[1].Type)
+	}
+}
+
+func TestTestConnectionReachability(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	openPort := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+
+	// Reserve a port, then close it so nothing is listening there.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedPort := strconv.Itoa(closed.Addr().(*net.TCPAddr).Port)
+	closed.Close()
+
+	c := &Client{}
+	reachable := Connection{Name: "up", Protocol: "ssh", Parameters: map[string]string{"hostname": "127.0.0.1", "port": openPort}}
+	if err := c.TestConnectionReachability(context.Background(), reachable); err != nil {
+		t.Errorf("reachable: %v", err)
+	}
+
+	unreachable := Connection{Name: "down", Protocol: "ssh", Parameters: map[string]string{"hostname": "127.0.0.1", "port": closedPort}}
+	if err := c.TestConnectionReachability(context.Background(), unreachable); err == nil {
+		t.Error("closed port: expected error, got nil")
+	}
+
+	noHost := Connection{Name: "nohost", Protocol: "ssh", Parameters: map[string]string{"port": openPort}}
+	if err := c.TestConnectionReachability(context.Background(), noHost); err == nil {
+		t.Error("missing hostname: expected error, got nil")
+	}
+}
+
+func TestRenameConnection_changes_only_name(t *testing.T) {
+	current := Connection{Identifier: "42", Name: "db", ParentIdentifier: "7", Protocol: "ssh",
+		Attributes: NullableStringMap{"max-connections": "2"}}
+	params := map[string]string{"hostname": "db.internal", "port": "22"}
+	var put Connection
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42":
+			writeJSON(t, w, current)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/connections/42/parameters":
+			writeJSON(t, w, params)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/session/data/postgresql/connections/42":
+			mustReadJSON(t, r, &put)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	if err := c.RenameConnection(context.Background(), "42", "db-primary"); err != nil {
+		t.Fatalf("RenameConnection: %v", err)
+	}
+	want := current
+	want.Name = "db-primary"
+	want.Parameters = params
+	if !reflect.DeepEqual(put, want) {
+		t.Errorf("PUT body: got %+v, want %+v", put, want)
+	}
+}
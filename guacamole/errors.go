@@ -3,14 +3,31 @@ package guacamole
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
 // Guacamole API error type constants.
 const (
-	ErrTypeNotFound        = "NOT_FOUND"
+	ErrTypeBadRequest       = "BAD_REQUEST"
+	ErrTypeNotFound         = "NOT_FOUND"
 	ErrTypePermissionDenied = "PERMISSION_DENIED"
+	// ErrTypeInvalidCredentials is returned by the token exchange when the
+	// username or password is wrong.
+	ErrTypeInvalidCredentials = "INVALID_CREDENTIALS"
 )
 
+// ErrNotAuthenticated is returned by resource methods called before
+// Authenticate (or on a client with no token), instead of sending a request
+// the server would reject with a confusing permission error. Use
+// WithAllowAnonymous to disable this check.
+var ErrNotAuthenticated = errors.New("guacamole: client is not authenticated; call Authenticate first")
+
+// ErrUnexpectedContentType is returned when a successful response that should
+// carry JSON declares another Content-Type, typically because a misconfigured
+// reverse proxy answered with an HTML page instead of forwarding the request.
+var ErrUnexpectedContentType = errors.New("guacamole: unexpected response content type")
+
 // APIError represents an error response from the Guacamole REST API.
 type APIError struct {
 	// Message is the human-readable error description.
@@ -19,6 +36,10 @@ type APIError struct {
 	Type string `json:"type"`
 	// HTTPStatus is the HTTP status code of the response.
 	HTTPStatus int `json:"-"`
+	// BodyStatus is the status code some extensions embed in the error body
+	// as "statusCode", or 0 when the body has none. It can disagree with
+	// HTTPStatus, which remains authoritative for the Is* checks.
+	BodyStatus int `json:"-"`
 }
 
 func (e *APIError) Error() string {
@@ -38,6 +59,24 @@ func (e *APIError) IsPermissionDenied() bool {
 	return e.Type == ErrTypePermissionDenied
 }
 
+// IsInvalidCredentials reports whether the error is an authentication failure
+// caused by a wrong username or password (type "INVALID_CREDENTIALS").
+func (e *APIError) IsInvalidCredentials() bool {
+	return e.Type == ErrTypeInvalidCredentials
+}
+
+// IsAlreadyExists reports whether the error indicates that a resource with the
+// requested identifier already exists. Guacamole has no dedicated error type
+// for this; it reports duplicates as HTTP 400 / type "BAD_REQUEST" with an
+// "already exists" message, and some proxies and extensions use HTTP 409.
+func (e *APIError) IsAlreadyExists() bool {
+	if e.HTTPStatus == http.StatusConflict {
+		return true
+	}
+	return (e.Type == ErrTypeBadRequest || e.HTTPStatus == http.StatusBadRequest) &&
+		strings.Contains(strings.ToLower(e.Message), "already exists")
+}
+
 // IsNotFound is a convenience function that returns true when err (or any
 // error in its chain) is an *APIError with type "NOT_FOUND". It returns false
 // for any other error type, including nil.
@@ -58,3 +97,23 @@ func IsPermissionDenied(err error) bool {
 	}
 	return false
 }
+
+// IsInvalidCredentials is a convenience function that returns true when err
+// (or any error in its chain) is an *APIError with type "INVALID_CREDENTIALS".
+func IsInvalidCredentials(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsInvalidCredentials()
+	}
+	return false
+}
+
+// IsAlreadyExists is a convenience function that returns true when err (or any
+// error in its chain) is an *APIError reporting a duplicate resource.
+func IsAlreadyExists(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsAlreadyExists()
+	}
+	return false
+}
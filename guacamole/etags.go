@@ -0,0 +1,146 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrNotModified is returned by the List*Conditional methods on a client
+// created with WithConditionalRequests when the server answers 304 Not
+// Modified, meaning the resource is unchanged since the last conditional read
+// of the same path in the same session. Callers should keep using their
+// cached copy. Check for it with errors.Is.
+var ErrNotModified = errors.New("guacamole: resource not modified")
+
+// etagCache records the most recent ETag seen for each session token and
+// request path, so that a tag obtained by one session is never replayed by
+// another.
+type etagCache struct {
+	mu   sync.Mutex
+	tags map[etagKey]string
+}
+
+type etagKey struct {
+	token string
+	path  string
+}
+
+func (e *etagCache) get(token, path string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tags[etagKey{token, path}]
+}
+
+func (e *etagCache) set(token, path, tag string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if tag == "" {
+		delete(e.tags, etagKey{token, path})
+		return
+	}
+	e.tags[etagKey{token, path}] = tag
+}
+
+// clear forgets every recorded ETag.
+func (e *etagCache) clear() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	clear(e.tags)
+}
+
+// WithConditionalRequests enables ETag-based conditional GETs for the
+// List*Conditional methods, such as ListConnectionsConditional. Each of them
+// remembers the ETag of its last successful response and sends it back as
+// If-None-Match on the next call; a 304 reply surfaces as ErrNotModified
+// without decoding a body. This is useful for frequently polled endpoints
+// such as the connection list. All other methods, including the plain List
+// methods, always read the full resource. The recorded ETags are forgotten by
+// Logout and SwitchDataSource.
+func WithConditionalRequests() Option {
+	return func(c *Client) {
+		c.etags = &etagCache{tags: make(map[etagKey]string)}
+	}
+}
+
+// conditionalKey marks a context whose GETs may be answered with 304.
+type conditionalKey struct{}
+
+// conditional returns ctx marked so that a GET made with it on a client with
+// WithConditionalRequests is conditional. The mark is unexported so that it
+// only ever reaches the single GET of a List*Conditional method, never the
+// reads of helpers that cannot handle ErrNotModified.
+func conditional(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conditionalKey{}, true)
+}
+
+// isConditional reports whether a GET made with ctx should be conditional.
+func (c *Client) isConditional(ctx context.Context, method string) bool {
+	marked, _ := ctx.Value(conditionalKey{}).(bool)
+	return marked && c.etags != nil && method == http.MethodGet
+}
+
+// setIfNoneMatch adds the stored ETag for path to a conditional GET request.
+func (c *Client) setIfNoneMatch(ctx context.Context, req *http.Request, path string) {
+	if !c.isConditional(ctx, req.Method) {
+		return
+	}
+	if tag := c.etags.get(c.authToken, path); tag != "" {
+		req.Header.Set("If-None-Match", tag)
+	}
+}
+
+// recordETag stores the ETag of a successful conditional GET response for
+// path.
+func (c *Client) recordETag(ctx context.Context, resp *http.Response, path string) {
+	if resp.Request == nil || !c.isConditional(ctx, resp.Request.Method) {
+		return
+	}
+	c.etags.set(c.authToken, path, resp.Header.Get("ETag"))
+}
+
+// clearETags forgets every recorded ETag, if conditional requests are enabled.
+func (c *Client) clearETags() {
+	if c.etags != nil {
+		c.etags.clear()
+	}
+}
+
+// ListConnectionsConditional is ListConnections as a conditional GET: it
+// returns ErrNotModified when the connection list is unchanged since the
+// previous call. Without WithConditionalRequests it behaves exactly like
+// ListConnections.
+func (c *Client) ListConnectionsConditional(ctx context.Context) (map[string]Connection, error) {
+	return c.ListConnections(conditional(ctx))
+}
+
+// ListConnectionGroupsConditional is ListConnectionGroups as a conditional
+// GET; see ListConnectionsConditional.
+func (c *Client) ListConnectionGroupsConditional(ctx context.Context) (map[string]ConnectionGroup, error) {
+	return c.ListConnectionGroups(conditional(ctx))
+}
+
+// ListSharingProfilesConditional is ListSharingProfiles as a conditional GET;
+// see ListConnectionsConditional.
+func (c *Client) ListSharingProfilesConditional(ctx context.Context) (map[string]SharingProfile, error) {
+	return c.ListSharingProfiles(conditional(ctx))
+}
+
+// ListUsersConditional is ListUsers as a conditional GET; see
+// ListConnectionsConditional.
+func (c *Client) ListUsersConditional(ctx context.Context) (map[string]User, error) {
+	return c.ListUsers(conditional(ctx))
+}
+
+// ListUserGroupsConditional is ListUserGroups as a conditional GET; see
+// ListConnectionsConditional.
+func (c *Client) ListUserGroupsConditional(ctx context.Context) (map[string]UserGroup, error) {
+	return c.ListUserGroups(conditional(ctx))
+}
+
+// ListActiveConnectionsConditional is ListActiveConnections as a conditional
+// GET; see ListConnectionsConditional.
+func (c *Client) ListActiveConnectionsConditional(ctx context.Context) (map[string]ActiveConnection, error) {
+	return c.ListActiveConnections(conditional(ctx))
+}
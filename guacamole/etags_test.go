@@ -0,0 +1,165 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// etagServer serves the connection list with ETag "v1" and answers 304 to
+// any request that presents it.
+func etagServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(t, w, map[string]Connection{"1": {Identifier: "1", Name: "web"}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithConditionalRequests_not_modified(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			if got := r.Header.Get("If-None-Match"); got != "" {
+				t.Errorf("first request If-None-Match: got %q, want none", got)
+			}
+			w.Header().Set("ETag", `"v1"`)
+			writeJSON(t, w, map[string]Connection{"1": {Identifier: "1", Name: "web"}})
+			return
+		}
+		assertHeader(t, r, "If-None-Match", `"v1"`)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", srv.Client(), WithConditionalRequests())
+	conns, err := c.ListConnectionsConditional(context.Background())
+	if err != nil {
+		t.Fatalf("first ListConnectionsConditional: %v", err)
+	}
+	if len(conns) != 1 {
+		t.Fatalf("connections: got %d, want 1", len(conns))
+	}
+
+	conns, err = c.ListConnectionsConditional(context.Background())
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("second ListConnectionsConditional: got %v, want ErrNotModified", err)
+	}
+	if conns != nil {
+		t.Errorf("connections on 304: got %v, want nil", conns)
+	}
+}
+
+func TestConditionalRequests_disabled_by_default(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-None-Match"); got != "" {
+			t.Errorf("If-None-Match: got %q, want none", got)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeJSON(t, w, map[string]Connection{})
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+			t.Fatalf("ListConnectionsConditional: %v", err)
+		}
+	}
+}
+
+func TestConditionalRequests_plain_methods_unconditional(t *testing.T) {
+	srv := etagServer(t)
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", srv.Client(), WithConditionalRequests())
+	if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+		t.Fatalf("ListConnectionsConditional: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListConnections(context.Background()); err != nil {
+			t.Fatalf("ListConnections %d: %v", i, err)
+		}
+	}
+}
+
+func TestConditionalRequests_read_modify_write_repeats(t *testing.T) {
+	puts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connectionGroups/4")
+		switch r.Method {
+		case http.MethodGet:
+			if got := r.Header.Get("If-None-Match"); got != "" {
+				t.Errorf("If-None-Match on helper read: got %q, want none", got)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"g4"`)
+			writeJSON(t, w, ConnectionGroup{Identifier: "4", Name: "g", Type: ConnectionGroupTypeOrganizational})
+		case http.MethodPut:
+			puts++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", srv.Client(), WithConditionalRequests())
+	for i := 0; i < 2; i++ {
+		err := c.PatchConnectionGroup(context.Background(), "4", func(g *ConnectionGroup) {
+			g.Name = "renamed"
+		})
+		if err != nil {
+			t.Fatalf("PatchConnectionGroup run %d: %v", i+1, err)
+		}
+	}
+	if puts != 2 {
+		t.Errorf("PUTs: got %d, want 2", puts)
+	}
+}
+
+func TestConditionalRequests_keyed_by_session(t *testing.T) {
+	srv := etagServer(t)
+	c := NewClientWithToken(srv.URL, "tok-alice", "postgresql", srv.Client(), WithConditionalRequests())
+	if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+		t.Fatalf("ListConnectionsConditional: %v", err)
+	}
+	c.authToken = "tok-bob"
+	if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+		t.Errorf("ListConnectionsConditional with another token: got %v, want a full read", err)
+	}
+}
+
+func TestConditionalRequests_cleared_by_switch_data_source(t *testing.T) {
+	srv := etagServer(t)
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", srv.Client(), WithConditionalRequests())
+	c.availableDataSources = []string{"postgresql", "ldap"}
+	if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+		t.Fatalf("ListConnectionsConditional: %v", err)
+	}
+	if err := c.SwitchDataSource(context.Background(), "postgresql"); err != nil {
+		t.Fatalf("SwitchDataSource: %v", err)
+	}
+	if _, err := c.ListConnectionsConditional(context.Background()); err != nil {
+		t.Errorf("ListConnectionsConditional after SwitchDataSource: got %v, want a full read", err)
+	}
+}
+
+func TestConditionalRequests_cleared_by_logout(t *testing.T) {
+	c := NewClient("http://localhost", WithConditionalRequests())
+	c.authToken = "tok"
+	c.etags.set("tok", "/api/session/data/postgresql/connections", `"v1"`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	c.baseURL = srv.URL
+	if err := c.Logout(context.Background()); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if got := c.etags.get("tok", "/api/session/data/postgresql/connections"); got != "" {
+		t.Errorf("ETag after Logout: got %q, want none", got)
+	}
+}
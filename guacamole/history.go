@@ -2,7 +2,14 @@ package guacamole
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // HistoryEntry represents a single recorded connection session or login event.
@@ -28,10 +35,7 @@ type HistoryEntry struct {
 // optionally ordered by start date. Pass order as "-startDate" for descending
 // or "startDate" for ascending; pass an empty string for the server default.
 func (c *Client) ListConnectionHistory(ctx context.Context, order string) ([]HistoryEntry, error) {
-	path := c.dataPath("history", "connections")
-	if order != "" {
-		path += "?order=" + order
-	}
+	path := c.dataPath("history", "connections") + orderQuery(order)
 	var result []HistoryEntry
 	if err := c.get(ctx, path, &result); err != nil {
 		return nil, fmt.Errorf("guacamole: list connection history: %w", err)
@@ -39,10 +43,19 @@ func (c *Client) ListConnectionHistory(ctx context.Context, order string) ([]His
 	return result, nil
 }
 
-// GetConnectionHistory returns the session history for a specific connection.
+// GetConnectionHistory returns the session history for a specific connection
+// in the server's default order.
 func (c *Client) GetConnectionHistory(ctx context.Context, connectionID string) ([]HistoryEntry, error) {
+	return c.GetConnectionHistoryOrdered(ctx, connectionID, "")
+}
+
+// GetConnectionHistoryOrdered returns the session history for a specific
+// connection, ordered as for ListConnectionHistory: pass "-startDate" for most
+// recent first, "startDate" for oldest first, or "" for the server default.
+func (c *Client) GetConnectionHistoryOrdered(ctx context.Context, connectionID, order string) ([]HistoryEntry, error) {
+	path := c.dataPath("connections", connectionID, "history") + orderQuery(order)
 	var result []HistoryEntry
-	if err := c.get(ctx, c.dataPath("connections", connectionID, "history"), &result); err != nil {
+	if err := c.get(ctx, path, &result); err != nil {
 		return nil, fmt.Errorf("guacamole: get connection history %s: %w", connectionID, err)
 	}
 	return result, nil
@@ -56,3 +69,148 @@ func (c *Client) GetUserHistory(ctx context.Context, username string) ([]History
 	}
 	return result, nil
 }
+
+// orderQuery returns a "?order=..." query string for order, or "" when order
+// is empty.
+func orderQuery(order string) string {
+	if order == "" {
+		return ""
+	}
+	return "?" + url.Values{"order": {order}}.Encode()
+}
+
+// HistoryQuery filters and orders a connection history search.
+type HistoryQuery struct {
+	// Contains restricts results to entries matching every given string
+	// (matched by the server against the username, remote host, and
+	// connection name).
+	Contains []string
+	// Order lists sort properties such as "-startDate" (descending) or
+	// "startDate" (ascending), applied in order.
+	Order []string
+}
+
+// encode returns the query string for q, without a leading "?". It is empty
+// when q has no filters.
+func (q HistoryQuery) encode() string {
+	v := url.Values{}
+	for _, s := range q.Contains {
+		v.Add("contains", s)
+	}
+	for _, s := range q.Order {
+		v.Add("order", s)
+	}
+	return v.Encode()
+}
+
+// EachConnectionHistory streams the global connection history matching q,
+// calling fn once per entry as it is decoded. Only one entry is held in memory
+// at a time, so it is suitable for very large histories. If fn returns an
+// error, iteration stops and that error is returned.
+func (c *Client) EachConnectionHistory(ctx context.Context, q HistoryQuery, fn func(HistoryEntry) error) error {
+	path := c.dataPath("history", "connections")
+	if qs := q.encode(); qs != "" {
+		path += "?" + qs
+	}
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return fmt.Errorf("guacamole: list connection history: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := expectJSON(resp); err != nil {
+		return fmt.Errorf("guacamole: list connection history: %w", err)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("guacamole: decode connection history: %w", err)
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("guacamole: decode connection history: expected a JSON array, got %v", describeToken(tok))
+	}
+	for dec.More() {
+		var entry HistoryEntry
+		if err := dec.Decode(&entry); err != nil {
+			return fmt.Errorf("guacamole: decode connection history: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeToken names the JSON value that starts with tok, for error
+// messages.
+func describeToken(tok json.Token) string {
+	switch tok {
+	case json.Delim('{'):
+		return "an object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T %v", tok, tok)
+}
+
+// historyCSVHeader is the header row written by WriteConnectionHistoryCSV.
+var historyCSVHeader = []string{"username", "remoteHost", "startDate", "endDate", "duration", "active"}
+
+// historyCSVFlushEvery is the number of rows buffered between flushes in
+// WriteConnectionHistoryCSV.
+const historyCSVFlushEvery = 100
+
+// WriteConnectionHistoryCSV streams the global connection history matching q
+// to w as CSV with the columns username, remoteHost, startDate, endDate,
+// duration, and active. Dates are RFC 3339 in UTC and duration uses Go
+// duration syntax (e.g. "1h30m0s"); both endDate and duration are empty for
+// sessions that are still active. Output is flushed incrementally.
+func (c *Client) WriteConnectionHistoryCSV(ctx context.Context, w io.Writer, q HistoryQuery) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(historyCSVHeader); err != nil {
+		return fmt.Errorf("guacamole: write history csv: %w", err)
+	}
+
+	rows := 0
+	err := c.EachConnectionHistory(ctx, q, func(e HistoryEntry) error {
+		if err := cw.Write(historyCSVRecord(e)); err != nil {
+			return fmt.Errorf("guacamole: write history csv: %w", err)
+		}
+		rows++
+		if rows%historyCSVFlushEvery == 0 {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return fmt.Errorf("guacamole: write history csv: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("guacamole: write history csv: %w", err)
+	}
+	return nil
+}
+
+// historyCSVRecord converts e into a CSV row matching historyCSVHeader.
+func historyCSVRecord(e HistoryEntry) []string {
+	start := time.UnixMilli(e.StartDate).UTC()
+	var end, duration string
+	if e.EndDate != 0 {
+		endTime := time.UnixMilli(e.EndDate).UTC()
+		end = endTime.Format(time.RFC3339)
+		duration = endTime.Sub(start).String()
+	}
+	return []string{
+		e.Username,
+		e.RemoteHost,
+		start.Format(time.RFC3339),
+		end,
+		duration,
+		strconv.FormatBool(e.Active),
+	}
+}
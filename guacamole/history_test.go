@@ -0,0 +1,146 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEachConnectionHistory_query_and_early_stop(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/history/connections")
+		if got := r.URL.Query()["contains"]; len(got) != 1 || got[0] != "alice smith" {
+			t.Errorf("contains: got %v, want [alice smith]", got)
+		}
+		if got := r.URL.Query()["order"]; len(got) != 1 || got[0] != "-startDate" {
+			t.Errorf("order: got %v, want [-startDate]", got)
+		}
+		writeJSON(t, w, []HistoryEntry{{Username: "a"}, {Username: "b"}, {Username: "c"}})
+	})
+
+	stop := errors.New("stop")
+	var seen []string
+	err := c.EachConnectionHistory(context.Background(),
+		HistoryQuery{Contains: []string{"alice smith"}, Order: []string{"-startDate"}},
+		func(e HistoryEntry) error {
+			seen = append(seen, e.Username)
+			if len(seen) == 2 {
+				return stop
+			}
+			return nil
+		})
+	if !errors.Is(err, stop) {
+		t.Errorf("err: got %v, want stop sentinel", err)
+	}
+	if strings.Join(seen, ",") != "a,b" {
+		t.Errorf("seen: got %v, want [a b]", seen)
+	}
+}
+
+func TestEachConnectionHistory_rejects_non_array(t *testing.T) {
+	cases := map[string]string{
+		"object": `{"1":{"username":"alice"}}`,
+		"null":   `null`,
+		"string": `"maintenance"`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+			})
+			called := false
+			err := c.EachConnectionHistory(context.Background(), HistoryQuery{}, func(HistoryEntry) error {
+				called = true
+				return nil
+			})
+			if err == nil || !strings.Contains(err.Error(), "expected a JSON array") {
+				t.Errorf("err: got %v, want an expected-array error", err)
+			}
+			if called {
+				t.Error("fn called for a non-array body")
+			}
+		})
+	}
+}
+
+func TestWriteConnectionHistoryCSV(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/history/connections")
+		writeJSON(t, w, []HistoryEntry{
+			{
+				Username:   "alice",
+				RemoteHost: "10.0.0.1",
+				StartDate:  1700000000000,
+				EndDate:    1700005400000,
+			},
+			{
+				Username:   "bob",
+				RemoteHost: "10.0.0.2",
+				StartDate:  1700010000000,
+				Active:     true,
+			},
+		})
+	})
+
+	var buf strings.Builder
+	if err := c.WriteConnectionHistoryCSV(context.Background(), &buf, HistoryQuery{}); err != nil {
+		t.Fatalf("WriteConnectionHistoryCSV: %v", err)
+	}
+	want := "username,remoteHost,startDate,endDate,duration,active\n" +
+		"alice,10.0.0.1,2023-11-14T22:13:20Z,2023-11-14T23:43:20Z,1h30m0s,false\n" +
+		"bob,10.0.0.2,2023-11-15T01:00:00Z,,,true\n"
+	if buf.String() != want {
+		t.Errorf("csv:\ngot:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestListConnectionHistory_order(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/history/connections")
+		if got := r.URL.RawQuery; got != "order=-startDate" {
+			t.Errorf("query: got %q, want %q", got, "order=-startDate")
+		}
+		writeJSON(t, w, []HistoryEntry{})
+	})
+	if _, err := c.ListConnectionHistory(context.Background(), "-startDate"); err != nil {
+		t.Fatalf("ListConnectionHistory: %v", err)
+	}
+}
+
+func TestGetConnectionHistoryOrdered(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/connections/42/history")
+		if got := r.URL.Query().Get("order"); got != "-startDate" {
+			t.Errorf("order: got %q, want %q", got, "-startDate")
+		}
+		writeJSON(t, w, []HistoryEntry{
+			{Username: "bob", StartDate: 2000},
+			{Username: "alice", StartDate: 1000},
+		})
+	})
+	got, err := c.GetConnectionHistoryOrdered(context.Background(), "42", "-startDate")
+	if err != nil {
+		t.Fatalf("GetConnectionHistoryOrdered: %v", err)
+	}
+	if len(got) != 2 || got[0].Username != "bob" {
+		t.Errorf("entries: got %+v, want bob first", got)
+	}
+}
+
+func TestGetConnectionHistory_no_order(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/connections/42/history")
+		if r.URL.RawQuery != "" {
+			t.Errorf("query: got %q, want empty", r.URL.RawQuery)
+		}
+		writeJSON(t, w, []HistoryEntry{})
+	})
+	if _, err := c.GetConnectionHistory(context.Background(), "42"); err != nil {
+		t.Fatalf("GetConnectionHistory: %v", err)
+	}
+}
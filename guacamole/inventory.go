@@ -0,0 +1,99 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ResourceInventory holds every object of each resource type visible to the
+// authenticated user, keyed by identifier (username for Users). Each field
+// corresponds to one data source endpoint.
+type ResourceInventory struct {
+	Connections      map[string]Connection      `json:"connections"`
+	ConnectionGroups map[string]ConnectionGroup `json:"connectionGroups"`
+	SharingProfiles  map[string]SharingProfile  `json:"sharingProfiles"`
+	Users            map[string]User            `json:"users"`
+	UserGroups       map[string]UserGroup       `json:"userGroups"`
+}
+
+// ListAllResources lists connections, connection groups, sharing profiles,
+// users and user groups concurrently into one ResourceInventory. Identifiers
+// and usernames missing from list entries are filled in from their map keys.
+//
+// A failed list does not abort the others: the inventory is still returned,
+// with nil maps for the types that could not be listed, together with an
+// error joining every failure. Each failure names the list that failed.
+func (c *Client) ListAllResources(ctx context.Context) (*ResourceInventory, error) {
+	inv := &ResourceInventory{}
+	lists := map[string]func() error{
+		"connections": func() (err error) {
+			inv.Connections, err = c.ListConnections(ctx)
+			for id, v := range inv.Connections {
+				if v.Identifier == "" {
+					v.Identifier = id
+					inv.Connections[id] = v
+				}
+			}
+			return err
+		},
+		"connectionGroups": func() (err error) {
+			inv.ConnectionGroups, err = c.ListConnectionGroups(ctx)
+			for id, v := range inv.ConnectionGroups {
+				if v.Identifier == "" {
+					v.Identifier = id
+					inv.ConnectionGroups[id] = v
+				}
+			}
+			return err
+		},
+		"sharingProfiles": func() (err error) {
+			inv.SharingProfiles, err = c.ListSharingProfiles(ctx)
+			for id, v := range inv.SharingProfiles {
+				if v.Identifier == "" {
+					v.Identifier = id
+					inv.SharingProfiles[id] = v
+				}
+			}
+			return err
+		},
+		"users": func() (err error) {
+			inv.Users, err = c.ListUsers(ctx)
+			for name, v := range inv.Users {
+				if v.Username == "" {
+					v.Username = name
+					inv.Users[name] = v
+				}
+			}
+			return err
+		},
+		"userGroups": func() (err error) {
+			inv.UserGroups, err = c.ListUserGroups(ctx)
+			for id, v := range inv.UserGroups {
+				if v.Identifier == "" {
+					v.Identifier = id
+					inv.UserGroups[id] = v
+				}
+			}
+			return err
+		},
+	}
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	forEachBounded(names, len(names), func(name string) {
+		if err := lists[name](); err != nil {
+			mu.Lock()
+			errs[name] = err
+			mu.Unlock()
+		}
+	})
+	if len(errs) > 0 {
+		return inv, fmt.Errorf("guacamole: list all resources: %w", joinErrors(errs))
+	}
+	return inv, nil
+}
@@ -0,0 +1,83 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// inventoryHandler serves one object from each of the five list endpoints,
+// omitting identifiers so that backfilling is exercised. Endpoints named in
+// failing respond with a server error instead.
+func inventoryHandler(t *testing.T, failing ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		endpoint := strings.TrimPrefix(r.URL.Path, "/api/session/data/postgresql/")
+		for _, f := range failing {
+			if endpoint == f {
+				writeAPIError(t, w, http.StatusInternalServerError, "INTERNAL_ERROR", "boom")
+				return
+			}
+		}
+		switch endpoint {
+		case "connections":
+			writeJSON(t, w, map[string]Connection{"1": {Name: "db", Protocol: "ssh"}})
+		case "connectionGroups":
+			writeJSON(t, w, map[string]ConnectionGroup{"2": {Name: "Lab", Type: ConnectionGroupTypeOrganizational}})
+		case "sharingProfiles":
+			writeJSON(t, w, map[string]SharingProfile{"3": {Name: "watch", PrimaryConnectionIdentifier: "1"}})
+		case "users":
+			writeJSON(t, w, map[string]User{"alice": {}})
+		case "userGroups":
+			writeJSON(t, w, map[string]UserGroup{"ops": {}})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}
+}
+
+func TestListAllResources(t *testing.T) {
+	c := newTestClient(t, inventoryHandler(t))
+	inv, err := c.ListAllResources(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllResources: %v", err)
+	}
+	if got := inv.Connections["1"]; got.Identifier != "1" || got.Name != "db" {
+		t.Errorf("Connections: got %+v", inv.Connections)
+	}
+	if got := inv.ConnectionGroups["2"]; got.Identifier != "2" || got.Name != "Lab" {
+		t.Errorf("ConnectionGroups: got %+v", inv.ConnectionGroups)
+	}
+	if got := inv.SharingProfiles["3"]; got.Identifier != "3" || got.PrimaryConnectionIdentifier != "1" {
+		t.Errorf("SharingProfiles: got %+v", inv.SharingProfiles)
+	}
+	if got := inv.Users["alice"]; got.Username != "alice" {
+		t.Errorf("Users: got %+v", inv.Users)
+	}
+	if got := inv.UserGroups["ops"]; got.Identifier != "ops" {
+		t.Errorf("UserGroups: got %+v", inv.UserGroups)
+	}
+}
+
+func TestListAllResources_partial_failure(t *testing.T) {
+	c := newTestClient(t, inventoryHandler(t, "users", "sharingProfiles"))
+	inv, err := c.ListAllResources(context.Background())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, name := range []string{"list users", "list sharing profiles"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not name %q", err, name)
+		}
+	}
+	if inv == nil {
+		t.Fatal("expected partial inventory alongside error")
+	}
+	if inv.Users != nil || inv.SharingProfiles != nil {
+		t.Errorf("failed lists: got users=%v sharingProfiles=%v, want nil", inv.Users, inv.SharingProfiles)
+	}
+	if len(inv.Connections) != 1 || len(inv.ConnectionGroups) != 1 || len(inv.UserGroups) != 1 {
+		t.Errorf("successful lists missing: got %+v", inv)
+	}
+}
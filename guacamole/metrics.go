@@ -0,0 +1,82 @@
+package guacamole
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricsObserver receives one observation per HTTP request made by the
+// client, suitable for feeding Prometheus counters and histograms.
+//
+// op is a low-cardinality label made of the HTTP method and a templated path,
+// e.g. "GET users/{username}/permissions"; resource identifiers and the data
+// source name never appear in it. status is the HTTP status code, or 0 when
+// the request failed before a response was received. dur is the time taken to
+// receive the response headers.
+type MetricsObserver interface {
+	ObserveRequest(op string, status int, dur time.Duration)
+}
+
+// WithMetrics reports every request made by the client to m.
+func WithMetrics(m MetricsObserver) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// pathPlaceholders maps a collection segment to the placeholder substituted
+// for the identifier that follows it.
+var pathPlaceholders = map[string]string{
+	"connections":       "{connectionID}",
+	"connectionGroups":  "{connectionGroupID}",
+	"sharingProfiles":   "{sharingProfileID}",
+	"users":             "{username}",
+	"userGroups":        "{userGroupID}",
+	"activeConnections": "{activeConnectionID}",
+	"logs":              "{logName}",
+}
+
+// operationLabel returns the metrics label for a request, replacing the data
+// source and every resource identifier in apiPath (the request path relative
+// to the API prefix) with a placeholder:
+//
+//	GET /session/data/postgresql/users/alice → "GET users/{username}"
+func operationLabel(method, apiPath string) string {
+	p := strings.TrimPrefix(apiPath, "/")
+	p = strings.TrimPrefix(p, "session/")
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+
+	var out []string
+	switch {
+	case len(segs) >= 2 && segs[0] == "data":
+		// Drop "data" and the data source name.
+		segs = segs[2:]
+	case len(segs) >= 2 && segs[0] == "ext":
+		// Extension paths are opaque; keep only the namespace.
+		return method + " ext/" + segs[1]
+	}
+
+	for i := 0; i < len(segs); i++ {
+		seg := segs[i]
+		out = append(out, seg)
+		if i+1 >= len(segs) {
+			break
+		}
+		if seg == "history" {
+			// history/connections and history/users are list endpoints whose
+			// next segment is a history entry UUID.
+			out = append(out, segs[i+1])
+			i++
+			if i+1 < len(segs) {
+				out = append(out, "{historyID}")
+				i++
+			}
+			continue
+		}
+		if ph, ok := pathPlaceholders[seg]; ok {
+			out = append(out, ph)
+			i++
+		}
+	}
+	return method + " " + strings.Join(out, "/")
+}
@@ -0,0 +1,82 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	mu   sync.Mutex
+	ops  []string
+	sts  []int
+	durs []time.Duration
+}
+
+func (o *recordingObserver) ObserveRequest(op string, status int, dur time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ops = append(o.ops, op)
+	o.sts = append(o.sts, status)
+	o.durs = append(o.durs, dur)
+}
+
+func TestOperationLabel(t *testing.T) {
+	cases := []struct {
+		method, path, want string
+	}{
+		{"GET", "/session/data/postgresql/users", "GET users"},
+		{"GET", "/session/data/postgresql/users/alice", "GET users/{username}"},
+		{"PATCH", "/session/data/postgresql/users/bob%40example.com/permissions", "PATCH users/{username}/permissions"},
+		{"GET", "/session/data/mysql/connectionGroups/ROOT/tree", "GET connectionGroups/{connectionGroupID}/tree"},
+		{"GET", "/session/data/postgresql/connections/42/parameters", "GET connections/{connectionID}/parameters"},
+		{"PUT", "/session/data/postgresql/userGroups/admins", "PUT userGroups/{userGroupID}"},
+		{"GET", "/session/data/postgresql/history/connections", "GET history/connections"},
+		{"GET", "/session/data/postgresql/history/connections/0f1e/logs/rec-1", "GET history/connections/{historyID}/logs/{logName}"},
+		{"GET", "/session/data/postgresql/self/effectivePermissions", "GET self/effectivePermissions"},
+		{"GET", "/session/data/postgresql/schema/protocols", "GET schema/protocols"},
+		{"DELETE", "/session", "DELETE session"},
+		{"POST", "/tokens", "POST tokens"},
+		{"POST", "/session/ext/quickconnect/create", "POST ext/quickconnect"},
+	}
+	for _, tc := range cases {
+		if got := operationLabel(tc.method, tc.path); got != tc.want {
+			t.Errorf("operationLabel(%s %s): got %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestWithMetrics_observes_templated_op(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/users/nobody") {
+			writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "No such user.")
+			return
+		}
+		writeJSON(t, w, User{Username: "alice"})
+	})
+	obs := &recordingObserver{}
+	WithMetrics(obs)(c)
+
+	if _, err := c.GetUser(context.Background(), "alice"); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	_, _ = c.GetUser(context.Background(), "nobody")
+
+	if len(obs.ops) != 2 {
+		t.Fatalf("observations: got %d, want 2", len(obs.ops))
+	}
+	for _, op := range obs.ops {
+		if op != "GET users/{username}" {
+			t.Errorf("op: got %q, want %q", op, "GET users/{username}")
+		}
+		if strings.Contains(op, "alice") || strings.Contains(op, "nobody") || strings.Contains(op, "postgresql") {
+			t.Errorf("op %q leaks an identifier", op)
+		}
+	}
+	if obs.sts[0] != http.StatusOK || obs.sts[1] != http.StatusNotFound {
+		t.Errorf("statuses: got %v, want [200 404]", obs.sts)
+	}
+}
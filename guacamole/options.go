@@ -0,0 +1,160 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures optional Client behaviour. Options are passed to NewClient
+// and its variants and are applied in order after the client's defaults have
+// been set.
+type Option func(*Client)
+
+// applyOptions applies opts to c in order.
+func (c *Client) applyOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// cloneHTTPClient returns a shallow copy of the client's *http.Client so that
+// options can adjust it without mutating one supplied by the caller.
+func (c *Client) cloneHTTPClient() *http.Client {
+	var hc http.Client
+	if c.httpClient != nil {
+		hc = *c.httpClient
+	}
+	return &hc
+}
+
+// WithRoundTripper wraps the client's existing transport with wrap, keeping the
+// configured timeout and other http.Client settings intact. This is the hook
+// for adding metrics, tracing, or logging middleware. When no transport has
+// been configured, http.DefaultTransport is wrapped. Multiple WithRoundTripper
+// options compose: each wraps the result of the previous one.
+//
+// The caller's *http.Client (if one was supplied) is copied rather than
+// modified.
+func WithRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := c.cloneHTTPClient()
+		base := hc.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		hc.Transport = wrap(base)
+		c.httpClient = hc
+	}
+}
+
+// WithAllowAnonymous permits resource calls on a client that has no auth
+// token. By default such calls fail with ErrNotAuthenticated; enable this only
+// for servers configured to serve anonymous reads.
+func WithAllowAnonymous() Option {
+	return func(c *Client) {
+		c.allowAnonymous = true
+	}
+}
+
+// WithRequestIDHeader sets headerName on every request to the value extract
+// returns for the request's context, so that outbound Guacamole calls carry
+// the caller's correlation or trace ID. The header is omitted when extract
+// returns "".
+func WithRequestIDHeader(headerName string, extract func(ctx context.Context) string) Option {
+	return func(c *Client) {
+		c.requestIDHeader = headerName
+		c.requestIDFunc = extract
+	}
+}
+
+// WithResponseHook calls hook with every successful (2xx) API response before
+// its body is decoded, e.g. to log status codes and headers while debugging
+// server quirks. The hook must not read or close resp.Body.
+func WithResponseHook(hook func(resp *http.Response)) Option {
+	return func(c *Client) {
+		c.responseHook = hook
+	}
+}
+
+// WithTreeMaxDepth makes GetConnectionGroupTree fail when the returned tree
+// nests connection groups more than depth levels below the requested group,
+// guarding callers that recurse over the tree against malformed server data.
+// A depth of 0 (the default) disables the check.
+func WithTreeMaxDepth(depth int) Option {
+	return func(c *Client) {
+		c.treeMaxDepth = depth
+	}
+}
+
+// defaultAPIPrefix is where Guacamole serves its REST API below the web
+// application root.
+const defaultAPIPrefix = "/api"
+
+// WithAPIPrefix sets the path, relative to the base URL, under which the REST
+// API is served. The default is "/api"; change it for reverse proxies that
+// remap the API, e.g. WithAPIPrefix("/gateway/guac") makes authentication use
+// baseURL + "/gateway/guac/tokens". Pass "/" when the API is served at the
+// base URL itself.
+func WithAPIPrefix(prefix string) Option {
+	return func(c *Client) {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if prefix != "/" {
+			for strings.Contains(prefix, "//") {
+				prefix = strings.ReplaceAll(prefix, "//", "/")
+			}
+		}
+		c.apiPrefix = prefix
+	}
+}
+
+// WithProxy routes requests through the HTTP proxy at proxyURL (e.g.
+// "http://proxy.corp:3128"). An empty proxyURL uses http.ProxyFromEnvironment,
+// honouring HTTP_PROXY, HTTPS_PROXY and NO_PROXY. An unparseable proxyURL
+// makes every request fail with the parse error rather than bypass the proxy.
+//
+// The client's *http.Transport (or http.DefaultTransport when none is set) is
+// cloned, never modified. Any other http.RoundTripper, such as one already
+// wrapped by WithRoundTripper or supplied in a custom *http.Client, cannot be
+// given a proxy; rather than silently sending traffic direct, every request
+// then fails with an error saying so. Apply WithProxy before WithRoundTripper.
+func WithProxy(proxyURL string) Option {
+	return func(c *Client) {
+		hc := c.cloneHTTPClient()
+		base, ok := hc.Transport.(*http.Transport)
+		if hc.Transport == nil {
+			base, ok = http.DefaultTransport.(*http.Transport)
+		}
+		if !ok {
+			hc.Transport = failingRoundTripper{fmt.Errorf(
+				"guacamole: WithProxy cannot configure a transport of type %T; apply WithProxy before WithRoundTripper", hc.Transport)}
+			c.httpClient = hc
+			return
+		}
+		t := base.Clone()
+		switch u, err := url.Parse(proxyURL); {
+		case proxyURL == "":
+			t.Proxy = http.ProxyFromEnvironment
+		case err != nil:
+			t.Proxy = func(*http.Request) (*url.URL, error) {
+				return nil, fmt.Errorf("guacamole: invalid proxy URL: %w", err)
+			}
+		default:
+			t.Proxy = http.ProxyURL(u)
+		}
+		hc.Transport = t
+		c.httpClient = hc
+	}
+}
+
+// failingRoundTripper fails every request with err. It stands in for a
+// transport an option could not configure as asked.
+type failingRoundTripper struct {
+	err error
+}
+
+func (f failingRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
@@ -0,0 +1,234 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// countingRoundTripper returns a wrapper that increments n on every request.
+func countingRoundTripper(n *int32) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(n, 1)
+			return next.RoundTrip(r)
+		})
+	}
+}
+
+func TestWithRoundTripper_invoked_per_request(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]Connection{})
+	}))
+	t.Cleanup(srv.Close)
+
+	var n int32
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", nil, WithRoundTripper(countingRoundTripper(&n)))
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListConnections(context.Background()); err != nil {
+			t.Fatalf("ListConnections: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&n); got != 3 {
+		t.Errorf("round trips: got %d, want 3", got)
+	}
+	if c.httpClient.Timeout != 30*time.Second {
+		t.Errorf("Timeout: got %v, want default 30s preserved", c.httpClient.Timeout)
+	}
+}
+
+func TestWithRoundTripper_composes_and_copies_client(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Order"); got != "outer,inner" {
+			t.Errorf("X-Order: got %q, want %q", got, "outer,inner")
+		}
+		writeJSON(t, w, map[string]Connection{})
+	}))
+	t.Cleanup(srv.Close)
+
+	tag := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if v := r.Header.Get("X-Order"); v != "" {
+					r.Header.Set("X-Order", v+","+name)
+				} else {
+					r.Header.Set("X-Order", name)
+				}
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	supplied := srv.Client()
+	origTransport := supplied.Transport
+	c := NewClientWithHTTPClient(srv.URL, supplied, WithRoundTripper(tag("inner")), WithRoundTripper(tag("outer")))
+	c.authToken = "tok"
+	c.dataSource = "postgresql"
+
+	if _, err := c.ListConnections(context.Background()); err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+	if supplied.Transport != origTransport {
+		t.Error("caller-supplied *http.Client was mutated")
+	}
+}
+
+type requestIDKey struct{}
+
+func TestWithRequestIDHeader(t *testing.T) {
+	var got []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, present := r.Header["X-Request-Id"]
+		if present {
+			got = append(got, r.Header.Get("X-Request-ID"))
+		} else {
+			got = append(got, "<none>")
+		}
+		writeJSON(t, w, map[string]Connection{})
+	})
+	WithRequestIDHeader("X-Request-ID", func(ctx context.Context) string {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return id
+	})(c)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-123")
+	if _, err := c.ListConnections(ctx); err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+	if _, err := c.ListConnections(context.Background()); err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+	if len(got) != 2 || got[0] != "req-123" || got[1] != "<none>" {
+		t.Errorf("X-Request-ID: got %v, want [req-123 <none>]", got)
+	}
+}
+
+func TestWithAPIPrefix(t *testing.T) {
+	cases := []struct {
+		prefix             string
+		wantAuth, wantData string
+	}{
+		{"/gateway/guac", "/root/gateway/guac/tokens", "/root/gateway/guac/session/data/mysql/connections"},
+		{"gateway/guac/", "/root/gateway/guac/tokens", "/root/gateway/guac/session/data/mysql/connections"},
+		{"/", "/root/tokens", "/root/session/data/mysql/connections"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.prefix, func(t *testing.T) {
+			var paths []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.URL.Path == tc.wantAuth {
+					writeJSON(t, w, AuthResponse{AuthToken: "tok", DataSource: "mysql"})
+					return
+				}
+				writeJSON(t, w, map[string]Connection{})
+			}))
+			t.Cleanup(srv.Close)
+
+			obs := &recordingObserver{}
+			c := NewClientWithHTTPClient(srv.URL+"/root", srv.Client(), WithAPIPrefix(tc.prefix), WithMetrics(obs))
+			if err := c.Authenticate(context.Background(), "admin", "secret"); err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if _, err := c.ListConnections(context.Background()); err != nil {
+				t.Fatalf("ListConnections: %v", err)
+			}
+			if len(paths) != 2 || paths[0] != tc.wantAuth || paths[1] != tc.wantData {
+				t.Errorf("paths: got %v, want [%s %s]", paths, tc.wantAuth, tc.wantData)
+			}
+			if len(obs.ops) != 2 || obs.ops[0] != "POST tokens" || obs.ops[1] != "GET connections" {
+				t.Errorf("metric ops: got %v, want [POST tokens GET connections]", obs.ops)
+			}
+		})
+	}
+}
+
+func TestWithResponseHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Guac-Node", "node-2")
+		writeJSON(t, w, map[string]Connection{"1": {Identifier: "1"}})
+	}))
+	t.Cleanup(srv.Close)
+
+	var status int
+	var node string
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", srv.Client(), WithResponseHook(func(resp *http.Response) {
+		status = resp.StatusCode
+		node = resp.Header.Get("X-Guac-Node")
+	}))
+	conns, err := c.ListConnections(context.Background())
+	if err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+	if len(conns) != 1 {
+		t.Errorf("connections: got %d, want 1 (body must be left for decoding)", len(conns))
+	}
+	if status != http.StatusOK || node != "node-2" {
+		t.Errorf("hook saw status=%d node=%q, want 200 %q", status, node, "node-2")
+	}
+}
+
+func TestWithProxy_routes_through_proxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL.
+		if r.URL.Host != "guacamole.invalid" {
+			t.Errorf("proxied host: got %q, want %q", r.URL.Host, "guacamole.invalid")
+		}
+		atomic.AddInt32(&proxied, 1)
+		writeJSON(t, w, map[string]Connection{})
+	}))
+	t.Cleanup(proxy.Close)
+
+	supplied := &http.Client{Transport: &http.Transport{}}
+	origTransport := supplied.Transport
+	c := NewClientWithToken("http://guacamole.invalid", "tok", "postgresql", supplied, WithProxy(proxy.URL))
+
+	if _, err := c.ListConnections(context.Background()); err != nil {
+		t.Fatalf("ListConnections: %v", err)
+	}
+	if atomic.LoadInt32(&proxied) != 1 {
+		t.Errorf("proxied requests: got %d, want 1", proxied)
+	}
+	if supplied.Transport != origTransport || origTransport.(*http.Transport).Proxy != nil {
+		t.Error("caller-supplied transport was mutated")
+	}
+}
+
+func TestWithProxy_invalid_url_fails_requests(t *testing.T) {
+	c := NewClientWithToken("http://guacamole.invalid", "tok", "postgresql", nil, WithProxy("http://[::1"))
+	if _, err := c.ListConnections(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("err: got %v, want invalid proxy URL error", err)
+	}
+}
+
+func TestWithProxy_unconfigurable_transport_fails_requests(t *testing.T) {
+	direct := int32(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&direct, 1)
+		writeJSON(t, w, map[string]Connection{})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClientWithToken(srv.URL, "tok", "postgresql", nil,
+		WithRoundTripper(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(rt.RoundTrip)
+		}),
+		WithProxy("http://proxy.invalid:3128"))
+	_, err := c.ListConnections(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "WithProxy cannot configure") {
+		t.Errorf("err: got %v, want WithProxy configuration error", err)
+	}
+	if n := atomic.LoadInt32(&direct); n != 0 {
+		t.Errorf("requests sent direct: got %d, want 0", n)
+	}
+}
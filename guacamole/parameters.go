@@ -0,0 +1,278 @@
+package guacamole
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParameterBuilder is implemented by the typed connection parameter builders
+// (RecordingParameters and friends). Each builder emits only the keys it has
+// values for, so several builders can be combined with BuildParameters to form
+// a single Connection.Parameters map.
+type ParameterBuilder interface {
+	Parameters() map[string]string
+}
+
+// parameterValidator is implemented by builders that can check their own
+// fields before being merged.
+type parameterValidator interface {
+	Validate() error
+}
+
+// BuildParameters validates and merges the output of the given builders into
+// one parameter map, suitable for Connection.Parameters. Later builders win
+// when two emit the same key.
+func BuildParameters(builders ...ParameterBuilder) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, b := range builders {
+		if v, ok := b.(parameterValidator); ok {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+		for k, v := range b.Parameters() {
+			params[k] = v
+		}
+	}
+	return params, nil
+}
+
+// setString stores value under key when it is non-empty.
+func setString(params map[string]string, key, value string) {
+	if value != "" {
+		params[key] = value
+	}
+}
+
+// setInt stores value as a decimal string under key when it is non-zero.
+func setInt(params map[string]string, key string, value int) {
+	if value != 0 {
+		params[key] = strconv.Itoa(value)
+	}
+}
+
+// setBool stores "true" under key when value is set. Guacamole treats an
+// absent boolean parameter as false.
+func setBool(params map[string]string, key string, value bool) {
+	if value {
+		params[key] = "true"
+	}
+}
+
+// RecordingParameters configures graphical session recording for a
+// connection. Recordings are written by guacd to Path on the guacd host.
+type RecordingParameters struct {
+	// Path is the directory recordings are written to (recording-path).
+	Path string
+	// Name is the recording filename, which may contain parameter tokens
+	// such as ${GUAC_DATE} (recording-name).
+	Name string
+	// CreatePath creates Path if it does not exist (create-recording-path).
+	CreatePath bool
+	// ExcludeOutput omits graphical output, leaving only input events
+	// (recording-exclude-output).
+	ExcludeOutput bool
+	// ExcludeMouse omits mouse events (recording-exclude-mouse).
+	ExcludeMouse bool
+	// ExcludeTouch omits touch events (recording-exclude-touch).
+	ExcludeTouch bool
+	// IncludeKeys records key events (recording-include-keys).
+	IncludeKeys bool
+}
+
+// Parameters returns the recording-* connection parameters for p, omitting
+// empty and false fields.
+func (p RecordingParameters) Parameters() map[string]string {
+	params := make(map[string]string)
+	setString(params, "recording-path", p.Path)
+	setString(params, "recording-name", p.Name)
+	setBool(params, "create-recording-path", p.CreatePath)
+	setBool(params, "recording-exclude-output", p.ExcludeOutput)
+	setBool(params, "recording-exclude-mouse", p.ExcludeMouse)
+	setBool(params, "recording-exclude-touch", p.ExcludeTouch)
+	setBool(params, "recording-include-keys", p.IncludeKeys)
+	return params
+}
+
+// RemoteAppParameters configures an RDP connection to launch a single
+// RemoteApp program instead of a full desktop.
+type RemoteAppParameters struct {
+	// Program is the RemoteApp to launch, usually its alias prefixed with
+	// "||" such as "||notepad" (remote-app).
+	Program string
+	// WorkingDir is the working directory of the program (remote-app-dir).
+	WorkingDir string
+	// Arguments are the command-line arguments passed to the program
+	// (remote-app-args).
+	Arguments string
+}
+
+// Parameters returns the remote-app* connection parameters for p, omitting
+// empty fields.
+func (p RemoteAppParameters) Parameters() map[string]string {
+	params := make(map[string]string)
+	setString(params, "remote-app", p.Program)
+	setString(params, "remote-app-dir", p.WorkingDir)
+	setString(params, "remote-app-args", p.Arguments)
+	return params
+}
+
+// TerminalParameters configures the terminal emulator used by text protocols
+// (SSH, telnet and Kubernetes).
+type TerminalParameters struct {
+	// ColorScheme is a named scheme such as "green-black" or a custom
+	// "foreground: ...; background: ..." definition (color-scheme).
+	ColorScheme string
+	// FontName is the font family used to render text (font-name).
+	FontName string
+	// FontSize is the font size in points; zero uses the server default
+	// (font-size).
+	FontSize int
+	// Scrollback is the number of rows kept in the scrollback buffer; zero
+	// uses the server default (scrollback).
+	Scrollback int
+}
+
+// Validate rejects negative sizes.
+func (p TerminalParameters) Validate() error {
+	if p.FontSize < 0 {
+		return fmt.Errorf("guacamole: terminal font size must not be negative, got %d", p.FontSize)
+	}
+	if p.Scrollback < 0 {
+		return fmt.Errorf("guacamole: terminal scrollback must not be negative, got %d", p.Scrollback)
+	}
+	return nil
+}
+
+// Parameters returns the terminal display parameters for p, omitting empty
+// and zero fields.
+func (p TerminalParameters) Parameters() map[string]string {
+	params := make(map[string]string)
+	setString(params, "color-scheme", p.ColorScheme)
+	setString(params, "font-name", p.FontName)
+	setInt(params, "font-size", p.FontSize)
+	setInt(params, "scrollback", p.Scrollback)
+	return params
+}
+
+// SFTPParameters configures file transfer over SFTP for graphical protocols
+// (RDP and VNC) and SSH. Combine it with other builders via BuildParameters.
+// The connection fields default to those of the connection itself where the
+// protocol allows it.
+type SFTPParameters struct {
+	// Enabled turns on SFTP file transfer (enable-sftp).
+	Enabled bool
+	// Hostname is the SFTP server to connect to (sftp-hostname).
+	Hostname string
+	// Port is the SFTP server port; zero uses the default of 22 (sftp-port).
+	Port int
+	// Username is the SFTP login name (sftp-username).
+	Username string
+	// Password is the SFTP password (sftp-password).
+	Password string
+	// PrivateKey is a PEM-encoded private key for key authentication
+	// (sftp-private-key).
+	PrivateKey string
+	// Passphrase decrypts PrivateKey (sftp-passphrase).
+	Passphrase string
+	// RootDirectory is the directory exposed as the root of the file browser
+	// (sftp-root-directory).
+	RootDirectory string
+	// Directory is the default upload directory (sftp-directory).
+	Directory string
+	// DisableDownload prevents downloading files (sftp-disable-download).
+	DisableDownload bool
+	// DisableUpload prevents uploading files (sftp-disable-upload).
+	DisableUpload bool
+}
+
+// Validate rejects ports outside 0-65535.
+func (p SFTPParameters) Validate() error {
+	if p.Port < 0 || p.Port > 65535 {
+		return fmt.Errorf("guacamole: sftp port out of range: %d", p.Port)
+	}
+	return nil
+}
+
+// Parameters returns the enable-sftp and sftp-* connection parameters for p,
+// omitting empty, zero and false fields.
+func (p SFTPParameters) Parameters() map[string]string {
+	params := make(map[string]string)
+	setBool(params, "enable-sftp", p.Enabled)
+	setString(params, "sftp-hostname", p.Hostname)
+	setInt(params, "sftp-port", p.Port)
+	setString(params, "sftp-username", p.Username)
+	setString(params, "sftp-password", p.Password)
+	setString(params, "sftp-private-key", p.PrivateKey)
+	setString(params, "sftp-passphrase", p.Passphrase)
+	setString(params, "sftp-root-directory", p.RootDirectory)
+	setString(params, "sftp-directory", p.Directory)
+	setBool(params, "sftp-disable-download", p.DisableDownload)
+	setBool(params, "sftp-disable-upload", p.DisableUpload)
+	return params
+}
+
+// KubernetesParameters configures a connection using the "kubernetes"
+// protocol, which attaches to (or execs in) a container of a pod through the
+// Kubernetes API server. Combine it with TerminalParameters for display
+// settings.
+type KubernetesParameters struct {
+	// Hostname is the Kubernetes API server (hostname).
+	Hostname string
+	// Port is the API server port; zero uses the default of 8080 (port).
+	Port int
+	// Namespace is the namespace of the pod (namespace). Required.
+	Namespace string
+	// Pod is the name of the pod to attach to (pod). Required.
+	Pod string
+	// Container selects a container within the pod; empty means the first
+	// (container).
+	Container string
+	// ExecCommand runs this command in the container instead of attaching
+	// to its primary process (exec-command).
+	ExecCommand string
+	// UseSSL connects to the API server over TLS (use-ssl).
+	UseSSL bool
+	// IgnoreCert skips verification of the API server certificate
+	// (ignore-cert).
+	IgnoreCert bool
+	// CACert is the PEM certificate authority used to verify the API server
+	// (ca-cert).
+	CACert string
+	// ClientCert and ClientKey are the PEM client certificate and key used
+	// to authenticate to the API server (client-cert, client-key).
+	ClientCert string
+	ClientKey  string
+}
+
+// Validate requires Namespace and Pod and rejects ports outside 0-65535.
+func (p KubernetesParameters) Validate() error {
+	if p.Namespace == "" {
+		return fmt.Errorf("guacamole: kubernetes namespace is required")
+	}
+	if p.Pod == "" {
+		return fmt.Errorf("guacamole: kubernetes pod is required")
+	}
+	if p.Port < 0 || p.Port > 65535 {
+		return fmt.Errorf("guacamole: kubernetes port out of range: %d", p.Port)
+	}
+	return nil
+}
+
+// Parameters returns the kubernetes connection parameters for p, omitting
+// empty, zero and false fields.
+func (p KubernetesParameters) Parameters() map[string]string {
+	params := make(map[string]string)
+	setString(params, "hostname", p.Hostname)
+	setInt(params, "port", p.Port)
+	setString(params, "namespace", p.Namespace)
+	setString(params, "pod", p.Pod)
+	setString(params, "container", p.Container)
+	setString(params, "exec-command", p.ExecCommand)
+	setBool(params, "use-ssl", p.UseSSL)
+	setBool(params, "ignore-cert", p.IgnoreCert)
+	setString(params, "ca-cert", p.CACert)
+	setString(params, "client-cert", p.ClientCert)
+	setString(params, "client-key", p.ClientKey)
+	return params
+}
@@ -0,0 +1,87 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// UI patch operation types. Each names where the patch's HTML is placed
+// relative to the elements matched by the operation's selector.
+const (
+	UIPatchBefore          = "before"
+	UIPatchAfter           = "after"
+	UIPatchReplace         = "replace"
+	UIPatchBeforeChildren  = "before-children"
+	UIPatchAfterChildren   = "after-children"
+	UIPatchReplaceChildren = "replace-children"
+)
+
+// uiPatchTypes is the set of meta names that declare a UI patch operation.
+var uiPatchTypes = map[string]bool{
+	UIPatchBefore:          true,
+	UIPatchAfter:           true,
+	UIPatchReplace:         true,
+	UIPatchBeforeChildren:  true,
+	UIPatchAfterChildren:   true,
+	UIPatchReplaceChildren: true,
+}
+
+// UIPatch is an HTML patch that an extension applies to the Guacamole web
+// interface. Patches can only be read through the REST API; they are
+// installed by deploying extensions.
+type UIPatch struct {
+	// Content is the patch's HTML exactly as served.
+	Content string
+	// Operations lists where Content is applied, in document order, as
+	// declared by its <meta name="..." content="selector"> tags.
+	Operations []UIPatchOperation
+}
+
+// UIPatchOperation is one placement of a UIPatch.
+type UIPatchOperation struct {
+	// Type is one of the UIPatch* constants.
+	Type string
+	// Selector is the CSS selector of the elements the patch applies to.
+	Selector string
+}
+
+// ListPatches returns the UI patches installed on the server, in the order
+// the web interface applies them. The API serves each patch as an HTML
+// document; the placement declared in its meta tags is decoded into
+// Operations.
+func (c *Client) ListPatches(ctx context.Context) ([]UIPatch, error) {
+	var docs []string
+	if err := c.get(ctx, c.apiPath("/patches"), &docs); err != nil {
+		return nil, fmt.Errorf("guacamole: list patches: %w", err)
+	}
+	patches := make([]UIPatch, 0, len(docs))
+	for _, doc := range docs {
+		patches = append(patches, UIPatch{Content: doc, Operations: parseUIPatchOperations(doc)})
+	}
+	return patches, nil
+}
+
+var (
+	metaTagPattern   = regexp.MustCompile(`(?is)<meta\b(?:[^>"']|"[^"]*"|'[^']*')*>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>/]+))`)
+)
+
+// parseUIPatchOperations extracts the operations declared by the meta tags
+// of doc. Meta tags whose name is not a patch operation are ignored.
+func parseUIPatchOperations(doc string) []UIPatchOperation {
+	var ops []UIPatchOperation
+	for _, tag := range metaTagPattern.FindAllString(doc, -1) {
+		attrs := make(map[string]string)
+		for _, m := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+		}
+		name := strings.ToLower(attrs["name"])
+		if uiPatchTypes[name] {
+			ops = append(ops, UIPatchOperation{Type: name, Selector: attrs["content"]})
+		}
+	}
+	return ops
+}
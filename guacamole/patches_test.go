@@ -0,0 +1,55 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestListPatches(t *testing.T) {
+	docs := []string{
+		`<meta name="after" content=".login-ui > .login-dialog-middle">
+<div class="welcome">Authorised use only</div>`,
+		`<meta content='#user-menu' name="replace-children">
+<meta name="before" content="a[href=&quot;#/settings&quot;]">
+<meta charset="utf-8">
+<a href="#/help">Help</a>`,
+		`<p>no placement</p>`,
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/patches")
+		writeJSON(t, w, docs)
+	})
+	got, err := c.ListPatches(context.Background())
+	if err != nil {
+		t.Fatalf("ListPatches: %v", err)
+	}
+	want := []UIPatch{
+		{Content: docs[0], Operations: []UIPatchOperation{
+			{Type: UIPatchAfter, Selector: ".login-ui > .login-dialog-middle"},
+		}},
+		{Content: docs[1], Operations: []UIPatchOperation{
+			{Type: UIPatchReplaceChildren, Selector: "#user-menu"},
+			{Type: UIPatchBefore, Selector: `a[href="#/settings"]`},
+		}},
+		{Content: docs[2]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patches:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestListPatches_none(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []string{})
+	})
+	got, err := c.ListPatches(context.Background())
+	if err != nil {
+		t.Fatalf("ListPatches: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("patches: got %#v, want empty non-nil slice", got)
+	}
+}
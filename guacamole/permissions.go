@@ -0,0 +1,520 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// subtreeReadOps returns one op per connection group and connection in the
+// subtree rooted at groupID, built with groupOp and connOp. The ROOT group
+// itself is skipped since it is implicitly readable and cannot be granted.
+func (c *Client) subtreeReadOps(ctx context.Context, groupID string,
+	groupOp, connOp func(id, permission string) PatchOperation) ([]PatchOperation, error) {
+	tree, err := c.GetConnectionGroupTree(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if tree.Identifier == "" {
+		tree.Identifier = groupID
+	}
+
+	var ops []PatchOperation
+	tree.Walk(
+		func(g *ConnectionGroup) bool {
+			if g.Identifier != RootConnectionGroupIdentifier {
+				ops = append(ops, groupOp(g.Identifier, PermissionRead))
+			}
+			return true
+		},
+		func(conn *Connection) {
+			ops = append(ops, connOp(conn.Identifier, PermissionRead))
+		},
+	)
+	return ops, nil
+}
+
+// GrantUserGroupSubtreeRead grants the user READ on the connection group
+// identified by groupID and on every connection group and connection nested
+// beneath it. The subtree is read with a single tree fetch and all grants are
+// applied in one PATCH.
+func (c *Client) GrantUserGroupSubtreeRead(ctx context.Context, username, groupID string) error {
+	ops, err := c.subtreeReadOps(ctx, groupID, AddConnectionGroupPermission, AddConnectionPermission)
+	if err != nil {
+		return fmt.Errorf("guacamole: grant subtree read on %s to %s: %w", groupID, username, err)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return c.UpdateUserPermissions(ctx, username, ops)
+}
+
+// RevokeUserGroupSubtreeRead is the inverse of GrantUserGroupSubtreeRead: it
+// revokes the user's READ on the connection group and everything beneath it
+// in one PATCH.
+func (c *Client) RevokeUserGroupSubtreeRead(ctx context.Context, username, groupID string) error {
+	ops, err := c.subtreeReadOps(ctx, groupID, RemoveConnectionGroupPermission, RemoveConnectionPermission)
+	if err != nil {
+		return fmt.Errorf("guacamole: revoke subtree read on %s from %s: %w", groupID, username, err)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return c.UpdateUserPermissions(ctx, username, ops)
+}
+
+// knownSystemPermissions is the set of valid SystemPermission* values.
+var knownSystemPermissions = map[string]bool{
+	SystemPermissionCreateUser:            true,
+	SystemPermissionCreateUserGroup:       true,
+	SystemPermissionCreateConnection:      true,
+	SystemPermissionCreateConnectionGroup: true,
+	SystemPermissionCreateSharingProfile:  true,
+	SystemPermissionAdminister:            true,
+}
+
+// GrantUserGroupSystemPermissions grants every system permission in perms to
+// the user group in a single PATCH. Each value must be one of the
+// SystemPermission* constants; an unknown value is rejected before any request
+// is sent.
+func (c *Client) GrantUserGroupSystemPermissions(ctx context.Context, id string, perms []string) error {
+	ops := make([]PatchOperation, 0, len(perms))
+	for _, p := range perms {
+		if !knownSystemPermissions[p] {
+			return fmt.Errorf("guacamole: grant system permissions to group %s: unknown system permission %q", id, p)
+		}
+		ops = append(ops, AddSystemPermission(p))
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return c.UpdateUserGroupPermissions(ctx, id, ops)
+}
+
+// GetUsersEffectivePermissionsEach fetches the effective permissions of every
+// user in usernames concurrently, with a bounded number of requests in flight.
+// Successes and failures are returned in separate maps keyed by username, so
+// a single failing user does not prevent reporting on the rest.
+func (c *Client) GetUsersEffectivePermissionsEach(ctx context.Context, usernames []string) (map[string]*Permissions, map[string]error) {
+	var mu sync.Mutex
+	perms := make(map[string]*Permissions, len(usernames))
+	errs := make(map[string]error)
+	forEachBounded(dedupe(usernames), bulkWorkers, func(username string) {
+		p, err := c.GetUserEffectivePermissions(ctx, username)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[username] = err
+			return
+		}
+		perms[username] = p
+	})
+	return perms, errs
+}
+
+// GetUsersEffectivePermissions is like GetUsersEffectivePermissionsEach but
+// folds any per-user failures into a single error, in username order. The
+// permissions of users that were fetched successfully are returned even when
+// the error is non-nil.
+func (c *Client) GetUsersEffectivePermissions(ctx context.Context, usernames []string) (map[string]*Permissions, error) {
+	perms, errs := c.GetUsersEffectivePermissionsEach(ctx, usernames)
+	return perms, joinErrors(errs)
+}
+
+// GetUserGroupEffectivePermissions returns the permissions held by the user
+// group including those inherited from every group it belongs to, directly or
+// transitively. Guacamole has no effectivePermissions endpoint for groups, so
+// the set is assembled client-side by walking parent groups (cycles in the
+// membership graph are tolerated).
+func (c *Client) GetUserGroupEffectivePermissions(ctx context.Context, id string) (*Permissions, error) {
+	result := &Permissions{}
+	visited := map[string]bool{}
+	queue := []string{id}
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+		if visited[group] {
+			continue
+		}
+		visited[group] = true
+
+		perms, err := c.GetUserGroupPermissions(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("guacamole: get user group effective permissions %s: %w", id, err)
+		}
+		mergePermissions(result, perms)
+
+		parents, err := c.GetUserGroupParentGroups(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("guacamole: get user group effective permissions %s: %w", id, err)
+		}
+		queue = append(queue, parents...)
+	}
+	return result, nil
+}
+
+// UserAccessibleConnections returns the sorted identifiers of connections on
+// which the user holds READ, including via group membership.
+func (c *Client) UserAccessibleConnections(ctx context.Context, username string) ([]string, error) {
+	perms, err := c.GetUserEffectivePermissions(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return objectsWithPermission(perms.ConnectionPermissions, PermissionRead), nil
+}
+
+// UserGroupAccessibleConnections returns the sorted identifiers of
+// connections on which the user group holds READ, including permissions
+// inherited from its parent groups.
+func (c *Client) UserGroupAccessibleConnections(ctx context.Context, groupID string) ([]string, error) {
+	perms, err := c.GetUserGroupEffectivePermissions(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return objectsWithPermission(perms.ConnectionPermissions, PermissionRead), nil
+}
+
+// objectsWithPermission returns the sorted keys of objectPerms whose
+// permission list contains permission.
+func objectsWithPermission(objectPerms map[string][]string, permission string) []string {
+	ids := []string{}
+	for id, perms := range objectPerms {
+		for _, p := range perms {
+			if p == permission {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// mergePermissions adds every permission in src to dst, skipping duplicates.
+func mergePermissions(dst, src *Permissions) {
+	mergeObjectPermissions(&dst.ConnectionPermissions, src.ConnectionPermissions)
+	mergeObjectPermissions(&dst.ConnectionGroupPermissions, src.ConnectionGroupPermissions)
+	mergeObjectPermissions(&dst.SharingProfilePermissions, src.SharingProfilePermissions)
+	mergeObjectPermissions(&dst.ActiveConnectionPermissions, src.ActiveConnectionPermissions)
+	mergeObjectPermissions(&dst.UserPermissions, src.UserPermissions)
+	mergeObjectPermissions(&dst.UserGroupPermissions, src.UserGroupPermissions)
+	dst.SystemPermissions = appendMissing(dst.SystemPermissions, src.SystemPermissions)
+}
+
+// mergeObjectPermissions merges src into *dst, allocating *dst if needed.
+func mergeObjectPermissions(dst *map[string][]string, src map[string][]string) {
+	if len(src) == 0 {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string][]string, len(src))
+	}
+	for id, perms := range src {
+		(*dst)[id] = appendMissing((*dst)[id], perms)
+	}
+}
+
+// appendMissing appends each element of add not already present in list.
+func appendMissing(list, add []string) []string {
+	for _, a := range add {
+		found := false
+		for _, l := range list {
+			if l == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// ── Single-permission user helpers ───────────────────────────────────────────
+
+// GrantUserConnectionPermission grants the user a single permission on the
+// connection identified by connectionID.
+func (c *Client) GrantUserConnectionPermission(ctx context.Context, username, connectionID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddConnectionPermission(connectionID, permission)})
+}
+
+// RevokeUserConnectionPermission revokes a single permission the user holds on
+// the connection identified by connectionID.
+func (c *Client) RevokeUserConnectionPermission(ctx context.Context, username, connectionID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveConnectionPermission(connectionID, permission)})
+}
+
+// GrantUserConnectionGroupPermission grants the user a single permission on the
+// connection group identified by groupID.
+func (c *Client) GrantUserConnectionGroupPermission(ctx context.Context, username, groupID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddConnectionGroupPermission(groupID, permission)})
+}
+
+// RevokeUserConnectionGroupPermission revokes a single permission the user holds on
+// the connection group identified by groupID.
+func (c *Client) RevokeUserConnectionGroupPermission(ctx context.Context, username, groupID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveConnectionGroupPermission(groupID, permission)})
+}
+
+// GrantUserSharingProfilePermission grants the user a single permission on the
+// sharing profile identified by profileID.
+func (c *Client) GrantUserSharingProfilePermission(ctx context.Context, username, profileID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddSharingProfilePermission(profileID, permission)})
+}
+
+// RevokeUserSharingProfilePermission revokes a single permission the user holds on
+// the sharing profile identified by profileID.
+func (c *Client) RevokeUserSharingProfilePermission(ctx context.Context, username, profileID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveSharingProfilePermission(profileID, permission)})
+}
+
+// GrantUserUserPermission grants the user a single permission on the
+// user identified by targetUsername.
+func (c *Client) GrantUserUserPermission(ctx context.Context, username, targetUsername, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddUserPermission(targetUsername, permission)})
+}
+
+// RevokeUserUserPermission revokes a single permission the user holds on
+// the user identified by targetUsername.
+func (c *Client) RevokeUserUserPermission(ctx context.Context, username, targetUsername, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveUserPermission(targetUsername, permission)})
+}
+
+// GrantUserUserGroupPermission grants the user a single permission on the
+// user group identified by groupID.
+func (c *Client) GrantUserUserGroupPermission(ctx context.Context, username, groupID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddUserGroupPermission(groupID, permission)})
+}
+
+// RevokeUserUserGroupPermission revokes a single permission the user holds on
+// the user group identified by groupID.
+func (c *Client) RevokeUserUserGroupPermission(ctx context.Context, username, groupID, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveUserGroupPermission(groupID, permission)})
+}
+
+// GrantUserSystemPermission grants the user a single system permission.
+func (c *Client) GrantUserSystemPermission(ctx context.Context, username, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{AddSystemPermission(permission)})
+}
+
+// RevokeUserSystemPermission revokes a single system permission from the user.
+func (c *Client) RevokeUserSystemPermission(ctx context.Context, username, permission string) error {
+	return c.UpdateUserPermissions(ctx, username, []PatchOperation{RemoveSystemPermission(permission)})
+}
+
+// ── Full-access helpers ──────────────────────────────────────────────────────
+
+// fullAccessPermissions are the object permissions that together amount to
+// full control of an object.
+var fullAccessPermissions = []string{PermissionRead, PermissionUpdate, PermissionDelete, PermissionAdminister}
+
+// fullAccessOps returns one op per entry of fullAccessPermissions on id.
+func fullAccessOps(id string, op func(id, permission string) PatchOperation) []PatchOperation {
+	ops := make([]PatchOperation, 0, len(fullAccessPermissions))
+	for _, p := range fullAccessPermissions {
+		ops = append(ops, op(id, p))
+	}
+	return ops
+}
+
+// GrantUserFullConnectionAccess grants the user READ, UPDATE, DELETE and
+// ADMINISTER on the connection identified by connectionID in one PATCH.
+func (c *Client) GrantUserFullConnectionAccess(ctx context.Context, username, connectionID string) error {
+	return c.UpdateUserPermissions(ctx, username, fullAccessOps(connectionID, AddConnectionPermission))
+}
+
+// GrantUserFullConnectionGroupAccess grants the user READ, UPDATE, DELETE and
+// ADMINISTER on the connection group identified by groupID in one PATCH.
+func (c *Client) GrantUserFullConnectionGroupAccess(ctx context.Context, username, groupID string) error {
+	return c.UpdateUserPermissions(ctx, username, fullAccessOps(groupID, AddConnectionGroupPermission))
+}
+
+// GrantUserFullSharingProfileAccess grants the user READ, UPDATE, DELETE and
+// ADMINISTER on the sharing profile identified by profileID in one PATCH.
+func (c *Client) GrantUserFullSharingProfileAccess(ctx context.Context, username, profileID string) error {
+	return c.UpdateUserPermissions(ctx, username, fullAccessOps(profileID, AddSharingProfilePermission))
+}
+
+// GrantUserGroupFullConnectionAccess grants the user group READ, UPDATE,
+// DELETE and ADMINISTER on the connection identified by connectionID in one
+// PATCH.
+func (c *Client) GrantUserGroupFullConnectionAccess(ctx context.Context, groupID, connectionID string) error {
+	return c.UpdateUserGroupPermissions(ctx, groupID, fullAccessOps(connectionID, AddConnectionPermission))
+}
+
+// GrantUserGroupFullConnectionGroupAccess grants the user group READ, UPDATE,
+// DELETE and ADMINISTER on the connection group identified by
+// connectionGroupID in one PATCH.
+func (c *Client) GrantUserGroupFullConnectionGroupAccess(ctx context.Context, groupID, connectionGroupID string) error {
+	return c.UpdateUserGroupPermissions(ctx, groupID, fullAccessOps(connectionGroupID, AddConnectionGroupPermission))
+}
+
+// GrantUserGroupFullSharingProfileAccess grants the user group READ, UPDATE,
+// DELETE and ADMINISTER on the sharing profile identified by profileID in one
+// PATCH.
+func (c *Client) GrantUserGroupFullSharingProfileAccess(ctx context.Context, groupID, profileID string) error {
+	return c.UpdateUserGroupPermissions(ctx, groupID, fullAccessOps(profileID, AddSharingProfilePermission))
+}
+
+// ── Declarative replacement ──────────────────────────────────────────────────
+
+// PermissionDiff describes how one permission set differs from another.
+// Granted holds the permissions present only in the target set and Revoked
+// those present only in the original; each object permission list is sorted.
+type PermissionDiff struct {
+	Granted Permissions
+	Revoked Permissions
+}
+
+// IsEmpty reports whether the diff contains no changes.
+func (d PermissionDiff) IsEmpty() bool {
+	return permissionsEmpty(&d.Granted) && permissionsEmpty(&d.Revoked)
+}
+
+// permissionCategory pairs an object permission map of Permissions with the
+// patch helpers that grant and revoke entries in it.
+type permissionCategory struct {
+	field       func(*Permissions) *map[string][]string
+	add, remove func(id, permission string) PatchOperation
+}
+
+// patchableCategories lists the object permission categories that can be
+// modified with PATCH, in the order their operations are emitted. Active
+// connection permissions are transient and are not included.
+var patchableCategories = []permissionCategory{
+	{func(p *Permissions) *map[string][]string { return &p.ConnectionPermissions }, AddConnectionPermission, RemoveConnectionPermission},
+	{func(p *Permissions) *map[string][]string { return &p.ConnectionGroupPermissions }, AddConnectionGroupPermission, RemoveConnectionGroupPermission},
+	{func(p *Permissions) *map[string][]string { return &p.SharingProfilePermissions }, AddSharingProfilePermission, RemoveSharingProfilePermission},
+	{func(p *Permissions) *map[string][]string { return &p.UserPermissions }, AddUserPermission, RemoveUserPermission},
+	{func(p *Permissions) *map[string][]string { return &p.UserGroupPermissions }, AddUserGroupPermission, RemoveUserGroupPermission},
+}
+
+// diffPermissions returns the changes that turn current into desired across
+// the patchable categories and system permissions.
+func diffPermissions(current, desired *Permissions) PermissionDiff {
+	var d PermissionDiff
+	for _, cat := range patchableCategories {
+		*cat.field(&d.Granted) = diffObjectPermissions(*cat.field(desired), *cat.field(current))
+		*cat.field(&d.Revoked) = diffObjectPermissions(*cat.field(current), *cat.field(desired))
+	}
+	d.Granted.SystemPermissions = missingFrom(desired.SystemPermissions, current.SystemPermissions)
+	d.Revoked.SystemPermissions = missingFrom(current.SystemPermissions, desired.SystemPermissions)
+	return d
+}
+
+// PermissionsDiff compares two permission sets without contacting the server.
+// added holds what b has and a lacks, removed what a has and b lacks, in every
+// category including active connection and system permissions. Object
+// permission maps are nil when a category has no changes and each list is
+// sorted and de-duplicated.
+func PermissionsDiff(a, b Permissions) (added, removed Permissions) {
+	d := diffPermissions(&a, &b)
+	d.Granted.ActiveConnectionPermissions = diffObjectPermissions(b.ActiveConnectionPermissions, a.ActiveConnectionPermissions)
+	d.Revoked.ActiveConnectionPermissions = diffObjectPermissions(a.ActiveConnectionPermissions, b.ActiveConnectionPermissions)
+	return d.Granted, d.Revoked
+}
+
+// diffObjectPermissions returns the entries of a that are absent from b, or
+// nil when there are none.
+func diffObjectPermissions(a, b map[string][]string) map[string][]string {
+	var out map[string][]string
+	for id, perms := range a {
+		if missing := missingFrom(perms, b[id]); len(missing) > 0 {
+			if out == nil {
+				out = make(map[string][]string)
+			}
+			out[id] = missing
+		}
+	}
+	return out
+}
+
+// missingFrom returns the sorted, de-duplicated elements of a not in b.
+func missingFrom(a, b []string) []string {
+	have := make(map[string]bool, len(b))
+	for _, v := range b {
+		have[v] = true
+	}
+	var out []string
+	for _, v := range a {
+		if !have[v] {
+			have[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// permissionsEmpty reports whether p holds no permissions at all.
+func permissionsEmpty(p *Permissions) bool {
+	return len(p.ConnectionPermissions) == 0 && len(p.ConnectionGroupPermissions) == 0 &&
+		len(p.SharingProfilePermissions) == 0 && len(p.ActiveConnectionPermissions) == 0 &&
+		len(p.UserPermissions) == 0 && len(p.UserGroupPermissions) == 0 &&
+		len(p.SystemPermissions) == 0
+}
+
+// ops returns the PATCH operations applying d: every revocation first, then
+// every grant, each in category, identifier and permission order.
+func (d PermissionDiff) ops() []PatchOperation {
+	var ops []PatchOperation
+	for _, revoke := range []bool{true, false} {
+		src := &d.Granted
+		if revoke {
+			src = &d.Revoked
+		}
+		for _, cat := range patchableCategories {
+			op := cat.add
+			if revoke {
+				op = cat.remove
+			}
+			m := *cat.field(src)
+			ids := make([]string, 0, len(m))
+			for id := range m {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				for _, p := range m[id] {
+					ops = append(ops, op(id, p))
+				}
+			}
+		}
+		for _, p := range src.SystemPermissions {
+			if revoke {
+				ops = append(ops, RemoveSystemPermission(p))
+			} else {
+				ops = append(ops, AddSystemPermission(p))
+			}
+		}
+	}
+	return ops
+}
+
+// ReplaceUserPermissions declaratively sets the user's direct permissions to
+// exactly desired, granting and revoking only what differs in a single PATCH.
+// Active connection permissions are ignored. No request is sent when nothing
+// changes.
+func (c *Client) ReplaceUserPermissions(ctx context.Context, username string, desired Permissions) error {
+	_, err := c.ReplaceUserPermissionsWithDiff(ctx, username, desired)
+	return err
+}
+
+// ReplaceUserPermissionsWithDiff is ReplaceUserPermissions, additionally
+// returning the changes it applied for logging or plan output.
+func (c *Client) ReplaceUserPermissionsWithDiff(ctx context.Context, username string, desired Permissions) (PermissionDiff, error) {
+	current, err := c.GetUserPermissions(ctx, username)
+	if err != nil {
+		return PermissionDiff{}, fmt.Errorf("guacamole: replace user permissions %s: %w", username, err)
+	}
+	diff := diffPermissions(current, &desired)
+	if diff.IsEmpty() {
+		return diff, nil
+	}
+	if err := c.UpdateUserPermissions(ctx, username, diff.ops()); err != nil {
+		return PermissionDiff{}, err
+	}
+	return diff, nil
+}
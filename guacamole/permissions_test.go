@@ -0,0 +1,492 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// subtreeFixture is a two-level connection group tree used by the subtree
+// permission tests.
+var subtreeFixture = ConnectionGroup{
+	Identifier: "7",
+	Name:       "Lab",
+	ChildConnections: []Connection{
+		{Identifier: "10", Name: "a"},
+	},
+	ChildConnectionGroups: []ConnectionGroup{
+		{
+			Identifier: "8",
+			Name:       "Nested",
+			ChildConnections: []Connection{
+				{Identifier: "11", Name: "b"},
+				{Identifier: "12", Name: "c"},
+			},
+		},
+	},
+}
+
+func TestGrantUserGroupSubtreeRead(t *testing.T) {
+	patches := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assertPath(t, r, "/api/session/data/postgresql/connectionGroups/7/tree")
+			writeJSON(t, w, subtreeFixture)
+		case http.MethodPatch:
+			patches++
+			assertPath(t, r, "/api/session/data/postgresql/users/alice/permissions")
+			var ops []PatchOperation
+			mustReadJSON(t, r, &ops)
+			want := []PatchOperation{
+				AddConnectionGroupPermission("7", PermissionRead),
+				AddConnectionPermission("10", PermissionRead),
+				AddConnectionGroupPermission("8", PermissionRead),
+				AddConnectionPermission("11", PermissionRead),
+				AddConnectionPermission("12", PermissionRead),
+			}
+			if len(ops) != len(want) {
+				t.Fatalf("ops: got %d (%+v), want %d", len(ops), ops, len(want))
+			}
+			for i := range want {
+				if ops[i] != want[i] {
+					t.Errorf("ops[%d]: got %+v, want %+v", i, ops[i], want[i])
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	if err := c.GrantUserGroupSubtreeRead(context.Background(), "alice", "7"); err != nil {
+		t.Fatalf("GrantUserGroupSubtreeRead: %v", err)
+	}
+	if patches != 1 {
+		t.Errorf("PATCH requests: got %d, want 1", patches)
+	}
+}
+
+func TestRevokeUserGroupSubtreeRead(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, subtreeFixture)
+		case http.MethodPatch:
+			var ops []PatchOperation
+			mustReadJSON(t, r, &ops)
+			if len(ops) != 5 {
+				t.Errorf("ops: got %d, want 5", len(ops))
+			}
+			for _, op := range ops {
+				if op.Op != "remove" || op.Value != PermissionRead {
+					t.Errorf("op: got %+v, want remove READ", op)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	if err := c.RevokeUserGroupSubtreeRead(context.Background(), "alice", "7"); err != nil {
+		t.Fatalf("RevokeUserGroupSubtreeRead: %v", err)
+	}
+}
+
+func TestGrantUserGroupSubtreeRead_skips_root(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, ConnectionGroup{
+				Identifier:       RootConnectionGroupIdentifier,
+				ChildConnections: []Connection{{Identifier: "1"}},
+			})
+		case http.MethodPatch:
+			var ops []PatchOperation
+			mustReadJSON(t, r, &ops)
+			if len(ops) != 1 || ops[0] != AddConnectionPermission("1", PermissionRead) {
+				t.Errorf("ops: got %+v, want only connection 1", ops)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	if err := c.GrantUserGroupSubtreeRead(context.Background(), "alice", RootConnectionGroupIdentifier); err != nil {
+		t.Fatalf("GrantUserGroupSubtreeRead: %v", err)
+	}
+}
+
+func TestGrantUserGroupSystemPermissions(t *testing.T) {
+	patches := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPatch)
+		assertPath(t, r, "/api/session/data/postgresql/userGroups/operators/permissions")
+		patches++
+		var ops []PatchOperation
+		mustReadJSON(t, r, &ops)
+		want := []PatchOperation{
+			AddSystemPermission(SystemPermissionCreateConnection),
+			AddSystemPermission(SystemPermissionCreateUser),
+		}
+		if len(ops) != len(want) || ops[0] != want[0] || ops[1] != want[1] {
+			t.Errorf("ops: got %+v, want %+v", ops, want)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	err := c.GrantUserGroupSystemPermissions(context.Background(), "operators", []string{
+		SystemPermissionCreateConnection,
+		SystemPermissionCreateUser,
+	})
+	if err != nil {
+		t.Fatalf("GrantUserGroupSystemPermissions: %v", err)
+	}
+	if patches != 1 {
+		t.Errorf("PATCH requests: got %d, want 1", patches)
+	}
+}
+
+func TestGrantUserGroupSystemPermissions_invalid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request for invalid permission", r.Method)
+	})
+	err := c.GrantUserGroupSystemPermissions(context.Background(), "operators", []string{
+		SystemPermissionCreateUser,
+		"CREATE_UNIVERSE",
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown permission, got nil")
+	}
+}
+
+func TestGetUsersEffectivePermissions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/users/alice/effectivePermissions":
+			writeJSON(t, w, Permissions{SystemPermissions: []string{SystemPermissionAdminister}})
+		case "/api/session/data/postgresql/users/bob/effectivePermissions":
+			writeJSON(t, w, Permissions{ConnectionPermissions: map[string][]string{"1": {PermissionRead}}})
+		case "/api/session/data/postgresql/users/carol/effectivePermissions":
+			writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission Denied.")
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	users := []string{"alice", "bob", "carol", "alice"}
+
+	perms, errs := c.GetUsersEffectivePermissionsEach(context.Background(), users)
+	if len(perms) != 2 || perms["alice"] == nil || perms["bob"] == nil {
+		t.Errorf("perms: got %v, want alice and bob", perms)
+	}
+	if len(errs) != 1 || !IsPermissionDenied(errs["carol"]) {
+		t.Errorf("errs: got %v, want only carol permission denied", errs)
+	}
+
+	perms, err := c.GetUsersEffectivePermissions(context.Background(), users)
+	if !IsPermissionDenied(err) {
+		t.Errorf("IsPermissionDenied: got false, want true (err=%v)", err)
+	}
+	if len(perms) != 2 {
+		t.Errorf("partial results: got %d users, want 2", len(perms))
+	}
+}
+
+func TestUserGroupAccessibleConnections(t *testing.T) {
+	// devs ⊂ staff ⊂ devs (cycle); only READ-bearing connections count.
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/userGroups/devs/permissions":
+			writeJSON(t, w, Permissions{ConnectionPermissions: map[string][]string{
+				"1": {PermissionRead},
+				"2": {PermissionUpdate},
+			}})
+		case "/api/session/data/postgresql/userGroups/devs/userGroups":
+			writeJSON(t, w, []string{"staff"})
+		case "/api/session/data/postgresql/userGroups/staff/permissions":
+			writeJSON(t, w, Permissions{ConnectionPermissions: map[string][]string{
+				"3": {PermissionAdminister, PermissionRead},
+				"1": {PermissionRead},
+			}})
+		case "/api/session/data/postgresql/userGroups/staff/userGroups":
+			writeJSON(t, w, []string{"devs"})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	got, err := c.UserGroupAccessibleConnections(context.Background(), "devs")
+	if err != nil {
+		t.Fatalf("UserGroupAccessibleConnections: %v", err)
+	}
+	if strings.Join(got, ",") != "1,3" {
+		t.Errorf("connections: got %v, want [1 3]", got)
+	}
+}
+
+func TestUserAccessibleConnections(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/users/alice/effectivePermissions")
+		writeJSON(t, w, Permissions{ConnectionPermissions: map[string][]string{
+			"5": {PermissionRead},
+			"6": {PermissionDelete},
+		}})
+	})
+	got, err := c.UserAccessibleConnections(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("UserAccessibleConnections: %v", err)
+	}
+	if len(got) != 1 || got[0] != "5" {
+		t.Errorf("connections: got %v, want [5]", got)
+	}
+}
+
+func TestUserSinglePermissionHelpers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func(c *Client) error
+		want PatchOperation
+	}{
+		{"grant connection", func(c *Client) error {
+			return c.GrantUserConnectionPermission(ctx, "alice", "42", PermissionRead)
+		}, AddConnectionPermission("42", PermissionRead)},
+		{"revoke connection", func(c *Client) error {
+			return c.RevokeUserConnectionPermission(ctx, "alice", "42", PermissionRead)
+		}, RemoveConnectionPermission("42", PermissionRead)},
+		{"grant connection group", func(c *Client) error {
+			return c.GrantUserConnectionGroupPermission(ctx, "alice", "7", PermissionUpdate)
+		}, AddConnectionGroupPermission("7", PermissionUpdate)},
+		{"revoke connection group", func(c *Client) error {
+			return c.RevokeUserConnectionGroupPermission(ctx, "alice", "7", PermissionUpdate)
+		}, RemoveConnectionGroupPermission("7", PermissionUpdate)},
+		{"grant sharing profile", func(c *Client) error {
+			return c.GrantUserSharingProfilePermission(ctx, "alice", "3", PermissionRead)
+		}, AddSharingProfilePermission("3", PermissionRead)},
+		{"revoke sharing profile", func(c *Client) error {
+			return c.RevokeUserSharingProfilePermission(ctx, "alice", "3", PermissionRead)
+		}, RemoveSharingProfilePermission("3", PermissionRead)},
+		{"grant user", func(c *Client) error {
+			return c.GrantUserUserPermission(ctx, "alice", "bob", PermissionDelete)
+		}, AddUserPermission("bob", PermissionDelete)},
+		{"revoke user", func(c *Client) error {
+			return c.RevokeUserUserPermission(ctx, "alice", "bob", PermissionDelete)
+		}, RemoveUserPermission("bob", PermissionDelete)},
+		{"grant user group", func(c *Client) error {
+			return c.GrantUserUserGroupPermission(ctx, "alice", "devs", PermissionAdminister)
+		}, AddUserGroupPermission("devs", PermissionAdminister)},
+		{"revoke user group", func(c *Client) error {
+			return c.RevokeUserUserGroupPermission(ctx, "alice", "devs", PermissionAdminister)
+		}, RemoveUserGroupPermission("devs", PermissionAdminister)},
+		{"grant system", func(c *Client) error {
+			return c.GrantUserSystemPermission(ctx, "alice", SystemPermissionCreateUser)
+		}, AddSystemPermission(SystemPermissionCreateUser)},
+		{"revoke system", func(c *Client) error {
+			return c.RevokeUserSystemPermission(ctx, "alice", SystemPermissionCreateUser)
+		}, RemoveSystemPermission(SystemPermissionCreateUser)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertMethod(t, r, http.MethodPatch)
+				assertPath(t, r, "/api/session/data/postgresql/users/alice/permissions")
+				var ops []PatchOperation
+				mustReadJSON(t, r, &ops)
+				if len(ops) != 1 || ops[0] != tt.want {
+					t.Errorf("ops: got %+v, want [%+v]", ops, tt.want)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+			if err := tt.call(c); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestGrantUserGroupFullConnectionAccess(t *testing.T) {
+	patches := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPatch)
+		assertPath(t, r, "/api/session/data/postgresql/userGroups/operators/permissions")
+		patches++
+		var ops []PatchOperation
+		mustReadJSON(t, r, &ops)
+		want := []PatchOperation{
+			AddConnectionPermission("5", PermissionRead),
+			AddConnectionPermission("5", PermissionUpdate),
+			AddConnectionPermission("5", PermissionDelete),
+			AddConnectionPermission("5", PermissionAdminister),
+		}
+		if len(ops) != len(want) {
+			t.Fatalf("ops: got %+v, want %+v", ops, want)
+		}
+		for i := range want {
+			if ops[i] != want[i] {
+				t.Errorf("op %d: got %+v, want %+v", i, ops[i], want[i])
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if err := c.GrantUserGroupFullConnectionAccess(context.Background(), "operators", "5"); err != nil {
+		t.Fatalf("GrantUserGroupFullConnectionAccess: %v", err)
+	}
+	if patches != 1 {
+		t.Errorf("PATCH requests: got %d, want 1", patches)
+	}
+}
+
+func TestGrantUserFullConnectionGroupAccess(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/users/alice/permissions")
+		var ops []PatchOperation
+		mustReadJSON(t, r, &ops)
+		if len(ops) != 4 {
+			t.Fatalf("ops: got %d, want 4", len(ops))
+		}
+		for _, op := range ops {
+			if op.Op != "add" || op.Path != "/connectionGroupPermissions/7" {
+				t.Errorf("op: got %+v", op)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if err := c.GrantUserFullConnectionGroupAccess(context.Background(), "alice", "7"); err != nil {
+		t.Fatalf("GrantUserFullConnectionGroupAccess: %v", err)
+	}
+}
+
+func TestReplaceUserPermissionsWithDiff(t *testing.T) {
+	current := Permissions{
+		ConnectionPermissions:      map[string][]string{"1": {PermissionRead}, "2": {PermissionRead, PermissionUpdate}},
+		ConnectionGroupPermissions: map[string][]string{"7": {PermissionRead}},
+		SystemPermissions:          []string{SystemPermissionCreateUser},
+	}
+	desired := Permissions{
+		ConnectionPermissions: map[string][]string{"2": {PermissionRead}, "3": {PermissionRead}},
+		UserPermissions:       map[string][]string{"bob": {PermissionRead}},
+		SystemPermissions:     []string{SystemPermissionCreateConnection},
+	}
+	var ops []PatchOperation
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/users/alice/permissions")
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, current)
+		case http.MethodPatch:
+			mustReadJSON(t, r, &ops)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	diff, err := c.ReplaceUserPermissionsWithDiff(context.Background(), "alice", desired)
+	if err != nil {
+		t.Fatalf("ReplaceUserPermissionsWithDiff: %v", err)
+	}
+
+	wantGranted := Permissions{
+		ConnectionPermissions: map[string][]string{"3": {PermissionRead}},
+		UserPermissions:       map[string][]string{"bob": {PermissionRead}},
+		SystemPermissions:     []string{SystemPermissionCreateConnection},
+	}
+	wantRevoked := Permissions{
+		ConnectionPermissions:      map[string][]string{"1": {PermissionRead}, "2": {PermissionUpdate}},
+		ConnectionGroupPermissions: map[string][]string{"7": {PermissionRead}},
+		SystemPermissions:          []string{SystemPermissionCreateUser},
+	}
+	if !reflect.DeepEqual(diff.Granted, wantGranted) {
+		t.Errorf("Granted: got %+v, want %+v", diff.Granted, wantGranted)
+	}
+	if !reflect.DeepEqual(diff.Revoked, wantRevoked) {
+		t.Errorf("Revoked: got %+v, want %+v", diff.Revoked, wantRevoked)
+	}
+
+	wantOps := []PatchOperation{
+		RemoveConnectionPermission("1", PermissionRead),
+		RemoveConnectionPermission("2", PermissionUpdate),
+		RemoveConnectionGroupPermission("7", PermissionRead),
+		RemoveSystemPermission(SystemPermissionCreateUser),
+		AddConnectionPermission("3", PermissionRead),
+		AddUserPermission("bob", PermissionRead),
+		AddSystemPermission(SystemPermissionCreateConnection),
+	}
+	if !reflect.DeepEqual(ops, wantOps) {
+		t.Errorf("ops: got %+v, want %+v", ops, wantOps)
+	}
+}
+
+func TestReplaceUserPermissions_no_change_sends_no_patch(t *testing.T) {
+	perms := Permissions{ConnectionPermissions: map[string][]string{"1": {PermissionRead}}}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		writeJSON(t, w, perms)
+	})
+	diff, err := c.ReplaceUserPermissionsWithDiff(context.Background(), "alice", perms)
+	if err != nil {
+		t.Fatalf("ReplaceUserPermissionsWithDiff: %v", err)
+	}
+	if !diff.IsEmpty() {
+		t.Errorf("diff: got %+v, want empty", diff)
+	}
+}
+
+func TestPermissionsDiff(t *testing.T) {
+	a := Permissions{
+		ConnectionPermissions:       map[string][]string{"1": {PermissionRead, PermissionUpdate}, "2": {PermissionRead}},
+		ConnectionGroupPermissions:  map[string][]string{"7": {PermissionRead}},
+		SharingProfilePermissions:   map[string][]string{"s1": {PermissionRead}},
+		ActiveConnectionPermissions: map[string][]string{"old-session": {PermissionDelete}},
+		UserPermissions:             map[string][]string{"bob": {PermissionRead}},
+		UserGroupPermissions:        map[string][]string{"devs": {PermissionRead, PermissionAdminister}},
+		SystemPermissions:           []string{SystemPermissionCreateUser, SystemPermissionCreateConnection},
+	}
+	b := Permissions{
+		ConnectionPermissions:       map[string][]string{"1": {PermissionRead, PermissionDelete, PermissionDelete}, "3": {PermissionRead}},
+		ConnectionGroupPermissions:  map[string][]string{"7": {PermissionRead}},
+		SharingProfilePermissions:   map[string][]string{"s2": {PermissionRead}},
+		ActiveConnectionPermissions: map[string][]string{"new-session": {PermissionRead}},
+		UserPermissions:             map[string][]string{"bob": {PermissionRead, PermissionUpdate}},
+		UserGroupPermissions:        map[string][]string{"devs": {PermissionRead}},
+		SystemPermissions:           []string{SystemPermissionCreateConnection, SystemPermissionAdminister},
+	}
+	added, removed := PermissionsDiff(a, b)
+
+	wantAdded := Permissions{
+		ConnectionPermissions:       map[string][]string{"1": {PermissionDelete}, "3": {PermissionRead}},
+		SharingProfilePermissions:   map[string][]string{"s2": {PermissionRead}},
+		ActiveConnectionPermissions: map[string][]string{"new-session": {PermissionRead}},
+		UserPermissions:             map[string][]string{"bob": {PermissionUpdate}},
+		SystemPermissions:           []string{SystemPermissionAdminister},
+	}
+	wantRemoved := Permissions{
+		ConnectionPermissions:       map[string][]string{"1": {PermissionUpdate}, "2": {PermissionRead}},
+		SharingProfilePermissions:   map[string][]string{"s1": {PermissionRead}},
+		ActiveConnectionPermissions: map[string][]string{"old-session": {PermissionDelete}},
+		UserGroupPermissions:        map[string][]string{"devs": {PermissionAdminister}},
+		SystemPermissions:           []string{SystemPermissionCreateUser},
+	}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added:\ngot  %+v\nwant %+v", added, wantAdded)
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removed:\ngot  %+v\nwant %+v", removed, wantRemoved)
+	}
+}
+
+func TestPermissionsDiff_identical_and_empty(t *testing.T) {
+	p := Permissions{
+		ConnectionPermissions: map[string][]string{"1": {PermissionUpdate, PermissionRead}},
+		SystemPermissions:     []string{SystemPermissionCreateUser},
+	}
+	q := Permissions{
+		ConnectionPermissions: map[string][]string{"1": {PermissionRead, PermissionUpdate}},
+		SystemPermissions:     []string{SystemPermissionCreateUser},
+	}
+	added, removed := PermissionsDiff(p, q)
+	if !permissionsEmpty(&added) || !permissionsEmpty(&removed) {
+		t.Errorf("same permissions in different order: got added %+v, removed %+v", added, removed)
+	}
+
+	added, removed = PermissionsDiff(Permissions{}, q)
+	if !reflect.DeepEqual(added, q) || !permissionsEmpty(&removed) {
+		t.Errorf("from empty: got added %+v, removed %+v", added, removed)
+	}
+	added, removed = PermissionsDiff(q, Permissions{})
+	if !permissionsEmpty(&added) || !reflect.DeepEqual(removed, q) {
+		t.Errorf("to empty: got added %+v, removed %+v", added, removed)
+	}
+}
@@ -0,0 +1,121 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Session log types reported by the history logs endpoint.
+const (
+	SessionLogTypeRecording        = "GUACAMOLE_SESSION_RECORDING"
+	SessionLogTypeTypescript       = "TYPESCRIPT"
+	SessionLogTypeTypescriptTiming = "TYPESCRIPT_TIMING"
+	SessionLogTypeServerLog        = "SERVER_LOG"
+)
+
+// SessionLog describes a log file (such as a session recording) associated
+// with a past connection session. Logs are exposed by Guacamole 1.5 and later
+// when the history recording storage extension is installed.
+type SessionLog struct {
+	// Name identifies the log within its history entry. It is taken from the
+	// key of the server's response map.
+	Name string `json:"-"`
+	// Type is one of the SessionLogType* constants.
+	Type string `json:"type"`
+	// Description is a translatable description of the log.
+	Description SessionLogDescription `json:"description"`
+}
+
+// SessionLogDescription is the translatable message describing a SessionLog.
+type SessionLogDescription struct {
+	Key       string                 `json:"key"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ListConnectionRecordings returns the graphical session recordings stored for
+// the past session identified by historyID (the history entry's UUID), sorted
+// by name. Other log types, such as typescripts, are excluded.
+func (c *Client) ListConnectionRecordings(ctx context.Context, historyID string) ([]SessionLog, error) {
+	var result map[string]SessionLog
+	if err := c.get(ctx, c.dataPath("history", "connections", historyID, "logs"), &result); err != nil {
+		return nil, fmt.Errorf("guacamole: list connection recordings %s: %w", historyID, err)
+	}
+	var recordings []SessionLog
+	for name, log := range result {
+		if log.Type != SessionLogTypeRecording {
+			continue
+		}
+		log.Name = name
+		recordings = append(recordings, log)
+	}
+	sort.Slice(recordings, func(i, j int) bool { return recordings[i].Name < recordings[j].Name })
+	return recordings, nil
+}
+
+// GetConnectionRecording opens the content of the recording named recordingID
+// (as returned by ListConnectionRecordings) for the past session identified by
+// historyID. The body is streamed rather than buffered, so recordings of any
+// size can be saved or transcoded; the caller must close the returned reader.
+func (c *Client) GetConnectionRecording(ctx context.Context, historyID, recordingID string) (io.ReadCloser, error) {
+	resp, err := c.doAccept(ctx, http.MethodGet, c.dataPath("history", "connections", historyID, "logs", recordingID), nil, "*/*")
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: get connection recording %s/%s: %w", historyID, recordingID, err)
+	}
+	return resp.Body, nil
+}
+
+// Recording is a downloadable session recording with the metadata a UI needs
+// to present it before download.
+type Recording struct {
+	SessionLog
+	// Size is the length of the recording in bytes, or -1 when the server
+	// does not report it.
+	Size int64
+	// Duration is the length of the recorded session, taken from its history
+	// entry. It is zero while the session is still active.
+	Duration time.Duration
+}
+
+// ListSessionRecordings is ListConnectionRecordings with typed metadata: the
+// history entry identified by historyUUID is read for the session duration
+// and each recording is probed with a HEAD request for its size, so nothing
+// is downloaded. Recordings are sorted by name.
+func (c *Client) ListSessionRecordings(ctx context.Context, historyUUID string) ([]Recording, error) {
+	logs, err := c.ListConnectionRecordings(ctx, historyUUID)
+	if err != nil {
+		return nil, err
+	}
+	var entry HistoryEntry
+	if err := c.get(ctx, c.dataPath("history", "connections", historyUUID), &entry); err != nil {
+		return nil, fmt.Errorf("guacamole: list session recordings %s: %w", historyUUID, err)
+	}
+	var duration time.Duration
+	if entry.EndDate != 0 && entry.EndDate >= entry.StartDate {
+		duration = time.Duration(entry.EndDate-entry.StartDate) * time.Millisecond
+	}
+
+	recordings := make([]Recording, 0, len(logs))
+	for _, log := range logs {
+		size, err := c.recordingSize(ctx, historyUUID, log.Name)
+		if err != nil {
+			return nil, fmt.Errorf("guacamole: list session recordings %s: %w", historyUUID, err)
+		}
+		recordings = append(recordings, Recording{SessionLog: log, Size: size, Duration: duration})
+	}
+	return recordings, nil
+}
+
+// recordingSize returns the Content-Length reported for a HEAD of the
+// recording, or -1 when there is none.
+func (c *Client) recordingSize(ctx context.Context, historyID, recordingID string) (int64, error) {
+	resp, err := c.doAccept(ctx, http.MethodHead, c.dataPath("history", "connections", historyID, "logs", recordingID), nil, "*/*")
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.ContentLength, nil
+}
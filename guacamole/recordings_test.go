@@ -0,0 +1,115 @@
+package guacamole
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestListConnectionRecordings(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/history/connections/0f1e2d3c-aaaa-bbbb-cccc-1234567890ab/logs")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"recording-2.guac": {"type": "GUACAMOLE_SESSION_RECORDING", "description": {"key": "APP.HISTORY_RECORDING"}},
+			"recording-1.guac": {"type": "GUACAMOLE_SESSION_RECORDING", "description": {"key": "APP.HISTORY_RECORDING", "variables": {"INDEX": 1}}},
+			"typescript": {"type": "TYPESCRIPT", "description": {"key": "APP.HISTORY_TYPESCRIPT"}}
+		}`))
+	})
+	got, err := c.ListConnectionRecordings(context.Background(), "0f1e2d3c-aaaa-bbbb-cccc-1234567890ab")
+	if err != nil {
+		t.Fatalf("ListConnectionRecordings: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len: got %d, want 2 (typescript excluded)", len(got))
+	}
+	if got[0].Name != "recording-1.guac" || got[1].Name != "recording-2.guac" {
+		t.Errorf("names: got %q, %q", got[0].Name, got[1].Name)
+	}
+	if got[0].Type != SessionLogTypeRecording || got[0].Description.Key != "APP.HISTORY_RECORDING" {
+		t.Errorf("recording: got %+v", got[0])
+	}
+	if got[0].Description.Variables["INDEX"] != float64(1) {
+		t.Errorf("variables: got %v", got[0].Description.Variables)
+	}
+}
+
+func TestGetConnectionRecording(t *testing.T) {
+	const content = "4.size,1.0,4.1024,3.768;"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/history/connections/abc/logs/recording-1.guac")
+		assertHeader(t, r, "Accept", "*/*")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte(content))
+	})
+	rc, err := c.GetConnectionRecording(context.Background(), "abc", "recording-1.guac")
+	if err != nil {
+		t.Fatalf("GetConnectionRecording: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("content: got %q, want %q", data, content)
+	}
+}
+
+func TestGetConnectionRecording_not_found(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "No such log.")
+	})
+	_, err := c.GetConnectionRecording(context.Background(), "abc", "missing")
+	if !IsNotFound(err) {
+		t.Errorf("IsNotFound: got false, want true (err=%v)", err)
+	}
+}
+
+func TestListSessionRecordings(t *testing.T) {
+	const uuid = "0f1e2d3c-aaaa-bbbb-cccc-1234567890ab"
+	base := "/api/session/data/postgresql/history/connections/" + uuid
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"/logs":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"recording-2.guac": {"type": "GUACAMOLE_SESSION_RECORDING", "description": {"key": "APP.HISTORY_RECORDING"}},
+				"recording-1.guac": {"type": "GUACAMOLE_SESSION_RECORDING", "description": {"key": "APP.HISTORY_RECORDING"}},
+				"typescript": {"type": "TYPESCRIPT", "description": {"key": "APP.HISTORY_TYPESCRIPT"}}
+			}`))
+		case r.Method == http.MethodGet && r.URL.Path == base:
+			writeJSON(t, w, HistoryEntry{UUID: uuid, StartDate: 1700000000000, EndDate: 1700000090500})
+		case r.Method == http.MethodHead && r.URL.Path == base+"/logs/recording-1.guac":
+			assertHeader(t, r, "Accept", "*/*")
+			w.Header().Set("Content-Length", "4096")
+		case r.Method == http.MethodHead && r.URL.Path == base+"/logs/recording-2.guac":
+			// Streamed without a known length.
+			w.Header().Set("Transfer-Encoding", "chunked")
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	got, err := c.ListSessionRecordings(context.Background(), uuid)
+	if err != nil {
+		t.Fatalf("ListSessionRecordings: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len: got %d, want 2", len(got))
+	}
+	if got[0].Name != "recording-1.guac" || got[0].Size != 4096 || got[0].Description.Key != "APP.HISTORY_RECORDING" {
+		t.Errorf("recording 1: got %+v", got[0])
+	}
+	if got[1].Name != "recording-2.guac" || got[1].Size != -1 {
+		t.Errorf("recording 2: got %+v, want size -1", got[1])
+	}
+	for _, rec := range got {
+		if rec.Duration != 90500*time.Millisecond {
+			t.Errorf("%s duration: got %v, want 1m30.5s", rec.Name, rec.Duration)
+		}
+	}
+}
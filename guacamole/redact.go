@@ -0,0 +1,158 @@
+package guacamole
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces sensitive values in redacted output.
+const redactedValue = "***"
+
+// sensitiveKeys lists JSON object keys whose values must never appear in
+// errors or logs: the user password and the connection parameters that carry
+// credentials or key material.
+var sensitiveKeys = map[string]bool{
+	"password":         true,
+	"passphrase":       true,
+	"private-key":      true,
+	"client-key":       true,
+	"gateway-password": true,
+	"sftp-password":    true,
+	"sftp-passphrase":  true,
+	"sftp-private-key": true,
+}
+
+// redactBody returns data with the values of all sensitive keys replaced by
+// "***". A JSON document is redacted at any depth; any other body, such as a
+// form-encoded or plain-text echo of a request, has key=value and key: value
+// pairs redacted. When nothing sensitive is found data is returned as is, so
+// harmless bodies reach error messages byte for byte.
+func redactBody(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return redactPairs(data)
+	}
+	if !redactValue(v) {
+		return data
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return redactPairs(data)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactValue walks a decoded JSON value, replacing sensitive entries, and
+// reports whether it replaced any. Keys match regardless of case, as they do
+// in redactPairs.
+func redactValue(v interface{}) bool {
+	redacted := false
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if sensitiveKeys[strings.ToLower(k)] {
+				t[k] = redactedValue
+				redacted = true
+			} else if redactValue(val) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, val := range t {
+			if redactValue(val) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// sensitivePairPattern matches a sensitive key followed by "=" or ":" and its
+// value, which runs to the next separator or closing bracket unless quoted. The key must not be
+// preceded by a name character, so "password" does not match inside
+// "sftp-password" (which is matched in its own right).
+var sensitivePairPattern = func() *regexp.Regexp {
+	keys := make([]string, 0, len(sensitiveKeys))
+	for k := range sensitiveKeys {
+		keys = append(keys, regexp.QuoteMeta(k))
+	}
+	sort.Strings(keys)
+	return regexp.MustCompile(`(?i)(^|[^a-z0-9_-])(` + strings.Join(keys, "|") +
+		`)(["']?\s*[=:]\s*)("[^"]*"|'[^']*'|[^&\s;,)\]}]*)`)
+}()
+
+// redactPairs redacts sensitive key=value and key: value pairs in a body
+// that is not JSON, returning data itself when there are none.
+func redactPairs(data []byte) []byte {
+	if !sensitivePairPattern.Match(data) {
+		return data
+	}
+	return sensitivePairPattern.ReplaceAll(data, []byte("${1}${2}${3}"+redactedValue))
+}
+
+// Sanitized returns a copy of u without its password, safe to log or cache.
+// The attribute map is copied, so u is never modified.
+func (u User) Sanitized() User {
+	u.Password = ""
+	u.Attributes = copyAttributes(u.Attributes)
+	return u
+}
+
+// Sanitized returns a copy of conn with every credential-bearing parameter
+// (see sensitiveKeys) removed, safe to log or cache. The parameter and
+// attribute maps are copied, so conn is never modified.
+func (conn Connection) Sanitized() Connection {
+	if conn.Parameters != nil {
+		params := make(map[string]string, len(conn.Parameters))
+		for k, v := range conn.Parameters {
+			if !sensitiveKeys[k] {
+				params[k] = v
+			}
+		}
+		conn.Parameters = params
+	}
+	conn.Attributes = copyAttributes(conn.Attributes)
+	return conn
+}
+
+// MaskSecrets returns a copy of params, such as the result of
+// GetConnectionParameters, in which the value of every known secret
+// parameter is replaced by "***" so the map can be logged or displayed.
+// Empty values are left empty, so an unset secret remains distinguishable.
+// params itself is not modified. The known secret parameters are:
+//
+//   - RDP: password, gateway-password
+//   - SSH: password, private-key, passphrase
+//   - VNC and telnet: password
+//   - Kubernetes: client-key
+//   - SFTP on any protocol: sftp-password, sftp-private-key, sftp-passphrase
+func MaskSecrets(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+	out := make(map[string]string, len(params))
+	for k, v := range params {
+		if sensitiveKeys[k] && v != "" {
+			v = redactedValue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// copyAttributes returns a shallow copy of m, preserving nil.
+func copyAttributes(m NullableStringMap) NullableStringMap {
+	if m == nil {
+		return nil
+	}
+	out := make(NullableStringMap, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Form is a named group of fields as described by the Guacamole schema
+// endpoints. The web UI renders each Form as a section of an edit page.
+type Form struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+}
+
+// Field describes a single attribute or parameter accepted by the server.
+//
+// Type is one of the Guacamole field types, e.g. "TEXT", "PASSWORD",
+// "NUMERIC", "BOOLEAN", "ENUM", "MULTILINE", "TIMEZONE", "DATE", "TIME" or
+// "EMAIL". Options lists the permitted values for ENUM fields and the
+// "true" value for BOOLEAN fields.
+type Field struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options,omitempty"`
+}
+
+// ProtocolInfo describes a protocol supported by the server along with the
+// parameters accepted by its connections and sharing profiles.
+type ProtocolInfo struct {
+	Name                string `json:"name"`
+	ConnectionForms     []Form `json:"connectionForms"`
+	SharingProfileForms []Form `json:"sharingProfileForms"`
+}
+
+// GetConnectionAttributeSchema returns the forms describing the attributes
+// accepted on connections (e.g. max-connections, guacd-hostname).
+func (c *Client) GetConnectionAttributeSchema(ctx context.Context) ([]Form, error) {
+	var result []Form
+	if err := c.get(ctx, c.dataPath("schema", "connectionAttributes"), &result); err != nil {
+		return nil, fmt.Errorf("guacamole: get connection attribute schema: %w", err)
+	}
+	return result, nil
+}
+
+// GetUserAttributeSchema returns the forms describing the attributes accepted
+// on users (e.g. guac-full-name, access-window-start).
+func (c *Client) GetUserAttributeSchema(ctx context.Context) ([]Form, error) {
+	var result []Form
+	if err := c.get(ctx, c.dataPath("schema", "userAttributes"), &result); err != nil {
+		return nil, fmt.Errorf("guacamole: get user attribute schema: %w", err)
+	}
+	return result, nil
+}
+
+// GetProtocolSchema returns every protocol supported by the server, keyed by
+// protocol name (e.g. "ssh", "rdp"), with the connection and sharing profile
+// parameters each accepts.
+func (c *Client) GetProtocolSchema(ctx context.Context) (map[string]ProtocolInfo, error) {
+	var result map[string]ProtocolInfo
+	if err := c.get(ctx, c.dataPath("schema", "protocols"), &result); err != nil {
+		return nil, fmt.Errorf("guacamole: get protocol schema: %w", err)
+	}
+	return result, nil
+}
+
+// ListProtocols returns the sorted names of the protocols the web application
+// defines forms for. The list comes from the protocol schema, so it says
+// nothing about which protocols guacd was built with.
+func (c *Client) ListProtocols(ctx context.Context) ([]string, error) {
+	schema, err := c.GetProtocolSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// connectionParameters returns the set of parameter names accepted by
+// connections using this protocol.
+func (p ProtocolInfo) connectionParameters() map[string]bool {
+	names := make(map[string]bool)
+	for _, form := range p.ConnectionForms {
+		for _, field := range form.Fields {
+			names[field.Name] = true
+		}
+	}
+	return names
+}
+
+// UnknownParametersError is returned by ValidateConnection when a connection
+// carries parameters that its protocol does not define.
+type UnknownParametersError struct {
+	Protocol string
+	// Parameters lists the unrecognised parameter names, sorted.
+	Parameters []string
+}
+
+func (e *UnknownParametersError) Error() string {
+	return fmt.Sprintf("guacamole: unknown %s parameters: %s", e.Protocol, strings.Join(e.Parameters, ", "))
+}
+
+// ValidateConnection fetches the protocol schema and checks conn against it
+// with ValidateConnectionWithSchema. Because this costs an extra request,
+// callers validating many connections should fetch the schema once with
+// GetProtocolSchema and call ValidateConnectionWithSchema directly.
+func (c *Client) ValidateConnection(ctx context.Context, conn Connection) error {
+	schema, err := c.GetProtocolSchema(ctx)
+	if err != nil {
+		return err
+	}
+	return ValidateConnectionWithSchema(conn, schema)
+}
+
+// ValidateConnectionWithSchema checks that conn.Protocol is present in schema
+// and that every key of conn.Parameters is a parameter of that protocol. It
+// returns an *UnknownParametersError listing any unrecognised keys, which
+// catches typos such as "hostnam" before the connection is created.
+func ValidateConnectionWithSchema(conn Connection, schema map[string]ProtocolInfo) error {
+	info, ok := schema[conn.Protocol]
+	if !ok {
+		return fmt.Errorf("guacamole: protocol %q is not supported by the server", conn.Protocol)
+	}
+	known := info.connectionParameters()
+	var unknown []string
+	for name := range conn.Parameters {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return &UnknownParametersError{Protocol: conn.Protocol, Parameters: unknown}
+}
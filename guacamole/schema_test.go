@@ -0,0 +1,168 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// sampleProtocolSchema is a trimmed /schema/protocols payload for ssh.
+const sampleProtocolSchema = `{
+  "ssh": {
+    "name": "ssh",
+    "connectionForms": [
+      {
+        "name": "network",
+        "fields": [
+          {"name": "hostname", "type": "TEXT"},
+          {"name": "port", "type": "NUMERIC"}
+        ]
+      },
+      {
+        "name": "authentication",
+        "fields": [
+          {"name": "username", "type": "USERNAME"},
+          {"name": "password", "type": "PASSWORD"}
+        ]
+      },
+      {
+        "name": "display",
+        "fields": [
+          {"name": "color-scheme", "type": "ENUM", "options": ["", "black-white", "gray-black"]}
+        ]
+      }
+    ],
+    "sharingProfileForms": [
+      {
+        "name": "display",
+        "fields": [
+          {"name": "read-only", "type": "BOOLEAN", "options": ["true"]}
+        ]
+      }
+    ]
+  }
+}`
+
+func TestGetProtocolSchema(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		assertPath(t, r, "/api/session/data/postgresql/schema/protocols")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(sampleProtocolSchema))
+	})
+	got, err := c.GetProtocolSchema(context.Background())
+	if err != nil {
+		t.Fatalf("GetProtocolSchema: %v", err)
+	}
+	ssh, ok := got["ssh"]
+	if !ok {
+		t.Fatal(`missing "ssh" protocol`)
+	}
+	if len(ssh.ConnectionForms) != 3 {
+		t.Fatalf("ConnectionForms: got %d, want 3", len(ssh.ConnectionForms))
+	}
+	if f := ssh.ConnectionForms[1].Fields[1]; f.Name != "password" || f.Type != "PASSWORD" {
+		t.Errorf("authentication field: got %+v, want password/PASSWORD", f)
+	}
+	if opts := ssh.ConnectionForms[2].Fields[0].Options; len(opts) != 3 || opts[1] != "black-white" {
+		t.Errorf("color-scheme options: got %v", opts)
+	}
+	if len(ssh.SharingProfileForms) != 1 || ssh.SharingProfileForms[0].Fields[0].Name != "read-only" {
+		t.Errorf("SharingProfileForms: got %+v", ssh.SharingProfileForms)
+	}
+}
+
+func TestListProtocols(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/schema/protocols")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+  "vnc": {"name": "vnc", "connectionForms": [], "sharingProfileForms": []},
+  "rdp": {"name": "rdp", "connectionForms": [], "sharingProfileForms": []},
+  "ssh": {"name": "ssh", "connectionForms": [], "sharingProfileForms": []}
+}`))
+	})
+	got, err := c.ListProtocols(context.Background())
+	if err != nil {
+		t.Fatalf("ListProtocols: %v", err)
+	}
+	if strings.Join(got, ",") != "rdp,ssh,vnc" {
+		t.Errorf("protocols: got %v, want [rdp ssh vnc]", got)
+	}
+}
+
+func TestGetConnectionAttributeSchema(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/schema/connectionAttributes")
+		writeJSON(t, w, []Form{
+			{Name: "concurrency", Fields: []Field{
+				{Name: "max-connections", Type: "NUMERIC"},
+				{Name: "max-connections-per-user", Type: "NUMERIC"},
+			}},
+		})
+	})
+	got, err := c.GetConnectionAttributeSchema(context.Background())
+	if err != nil {
+		t.Fatalf("GetConnectionAttributeSchema: %v", err)
+	}
+	if len(got) != 1 || len(got[0].Fields) != 2 || got[0].Fields[0].Name != "max-connections" {
+		t.Errorf("schema: got %+v", got)
+	}
+}
+
+func TestGetUserAttributeSchema(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/schema/userAttributes")
+		writeJSON(t, w, []Form{{Name: "profile", Fields: []Field{{Name: "guac-full-name", Type: "TEXT"}}}})
+	})
+	got, err := c.GetUserAttributeSchema(context.Background())
+	if err != nil {
+		t.Fatalf("GetUserAttributeSchema: %v", err)
+	}
+	if len(got) != 1 || got[0].Fields[0].Name != "guac-full-name" {
+		t.Errorf("schema: got %+v", got)
+	}
+}
+
+func TestValidateConnection_valid(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/schema/protocols")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(sampleProtocolSchema))
+	})
+	err := c.ValidateConnection(context.Background(), Connection{
+		Protocol:   "ssh",
+		Parameters: map[string]string{"hostname": "10.0.0.1", "port": "22", "password": "x"},
+	})
+	if err != nil {
+		t.Errorf("ValidateConnection: %v", err)
+	}
+}
+
+func TestValidateConnectionWithSchema_unknown_keys(t *testing.T) {
+	schema := map[string]ProtocolInfo{
+		"ssh": {Name: "ssh", ConnectionForms: []Form{
+			{Name: "network", Fields: []Field{{Name: "hostname"}, {Name: "port"}}},
+		}},
+	}
+	err := ValidateConnectionWithSchema(Connection{
+		Protocol:   "ssh",
+		Parameters: map[string]string{"hostnam": "10.0.0.1", "port": "22", "prot": "x"},
+	}, schema)
+	var unknownErr *UnknownParametersError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("err: got %v, want *UnknownParametersError", err)
+	}
+	if got := strings.Join(unknownErr.Parameters, ","); got != "hostnam,prot" {
+		t.Errorf("Parameters: got %s, want hostnam,prot", got)
+	}
+}
+
+func TestValidateConnectionWithSchema_unknown_protocol(t *testing.T) {
+	err := ValidateConnectionWithSchema(Connection{Protocol: "spice"}, map[string]ProtocolInfo{"ssh": {}})
+	if err == nil {
+		t.Fatal("expected error for unsupported protocol, got nil")
+	}
+}
@@ -3,6 +3,9 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"sort"
+	"time"
 )
 
 // Self represents the currently-authenticated user's profile as returned by
@@ -15,6 +18,19 @@ type Self struct {
 	Attributes NullableStringMap `json:"attributes,omitempty"`
 }
 
+// LastActiveTime returns LastActive as a time.Time. ok is false when
+// LastActive is zero.
+func (s *Self) LastActiveTime() (t time.Time, ok bool) {
+	return millisTime(s.LastActive)
+}
+
+// SeenWithin reports whether the current user was last active within d
+// before now.
+func (s *Self) SeenWithin(now time.Time, d time.Duration) bool {
+	t, ok := s.LastActiveTime()
+	return ok && now.Sub(t) <= d
+}
+
 // GetSelf returns the profile of the currently-authenticated user. This is
 // useful for validating credentials and retrieving the authenticated username
 // without knowing it in advance.
@@ -47,3 +63,112 @@ func (c *Client) GetSelfEffectivePermissions(ctx context.Context) (*Permissions,
 	}
 	return &result, nil
 }
+
+// IsAdministrator reports whether the currently-authenticated user holds the
+// ADMINISTER system permission, directly or through group membership.
+func (c *Client) IsAdministrator(ctx context.Context) (bool, error) {
+	perms, err := c.GetSelfEffectivePermissions(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range perms.SystemPermissions {
+		if p == SystemPermissionAdminister {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SelfSystemPermissions returns the system permissions of the
+// currently-authenticated user, including those inherited from groups, sorted
+// alphabetically.
+func (c *Client) SelfSystemPermissions(ctx context.Context) ([]string, error) {
+	perms, err := c.GetSelfEffectivePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := append([]string{}, perms.SystemPermissions...)
+	sort.Strings(result)
+	return result, nil
+}
+
+// PermissionQuery selects the objects of interest for
+// GetSelfEffectivePermissionsFor. Each slice lists object identifiers in one
+// permission category; System requests the system permissions as well.
+type PermissionQuery struct {
+	Connections      []string
+	ConnectionGroups []string
+	SharingProfiles  []string
+	Users            []string
+	UserGroups       []string
+	System           bool
+}
+
+// encode returns the query string for q, without a leading "?", repeating a
+// parameter per identifier (e.g. "connection=5&connection=7").
+func (q PermissionQuery) encode() string {
+	v := url.Values{}
+	for _, f := range []struct {
+		key string
+		ids []string
+	}{
+		{"connection", q.Connections},
+		{"connectionGroup", q.ConnectionGroups},
+		{"sharingProfile", q.SharingProfiles},
+		{"user", q.Users},
+		{"userGroup", q.UserGroups},
+	} {
+		for _, id := range f.ids {
+			v.Add(f.key, id)
+		}
+	}
+	return v.Encode()
+}
+
+// filter returns the part of p covering the objects selected by q.
+func (q PermissionQuery) filter(p *Permissions) *Permissions {
+	out := &Permissions{
+		ConnectionPermissions:      pickObjects(p.ConnectionPermissions, q.Connections),
+		ConnectionGroupPermissions: pickObjects(p.ConnectionGroupPermissions, q.ConnectionGroups),
+		SharingProfilePermissions:  pickObjects(p.SharingProfilePermissions, q.SharingProfiles),
+		UserPermissions:            pickObjects(p.UserPermissions, q.Users),
+		UserGroupPermissions:       pickObjects(p.UserGroupPermissions, q.UserGroups),
+	}
+	if q.System {
+		out.SystemPermissions = p.SystemPermissions
+	}
+	return out
+}
+
+// pickObjects returns the entries of m whose keys are in ids, or nil when
+// none are requested or held.
+func pickObjects(m map[string][]string, ids []string) map[string][]string {
+	var out map[string][]string
+	for _, id := range ids {
+		if perms, ok := m[id]; ok {
+			if out == nil {
+				out = make(map[string][]string, len(ids))
+			}
+			out[id] = perms
+		}
+	}
+	return out
+}
+
+// GetSelfEffectivePermissionsFor returns the current user's effective
+// permissions on just the objects selected by q, which keeps "may I modify
+// these three connections?" checks small. The identifiers are sent as query
+// parameters so servers that support filtering can trim the response; the
+// result is also filtered locally, so it never contains unrequested objects.
+// Objects on which the user holds no permissions are absent from the result.
+func (c *Client) GetSelfEffectivePermissionsFor(ctx context.Context, q PermissionQuery) (*Permissions, error) {
+	path := c.dataPath("self", "effectivePermissions")
+	if qs := q.encode(); qs != "" {
+		path += "?" + qs
+	}
+	var result Permissions
+	if err := c.get(ctx, path, &result); err != nil {
+		return nil, fmt.Errorf("guacamole: get self effective permissions: %w", err)
+	}
+	return q.filter(&result), nil
+}
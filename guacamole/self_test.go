@@ -0,0 +1,93 @@
+package guacamole
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsAdministrator(t *testing.T) {
+	cases := []struct {
+		name  string
+		perms []string
+		want  bool
+	}{
+		{"admin", []string{SystemPermissionCreateUser, SystemPermissionAdminister}, true},
+		{"non-admin", []string{SystemPermissionCreateConnection}, false},
+		{"no system permissions", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertMethod(t, r, http.MethodGet)
+				assertPath(t, r, "/api/session/data/postgresql/self/effectivePermissions")
+				writeJSON(t, w, Permissions{SystemPermissions: tc.perms})
+			})
+			got, err := c.IsAdministrator(context.Background())
+			if err != nil {
+				t.Fatalf("IsAdministrator: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("IsAdministrator: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSelfSystemPermissions_sorted(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/self/effectivePermissions")
+		writeJSON(t, w, Permissions{SystemPermissions: []string{
+			SystemPermissionCreateUser,
+			SystemPermissionAdminister,
+			SystemPermissionCreateConnection,
+		}})
+	})
+	got, err := c.SelfSystemPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("SelfSystemPermissions: %v", err)
+	}
+	want := []string{SystemPermissionAdminister, SystemPermissionCreateConnection, SystemPermissionCreateUser}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("permissions: got %v, want %v", got, want)
+	}
+}
+
+func TestGetSelfEffectivePermissionsFor(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/self/effectivePermissions")
+		q := r.URL.Query()
+		if got := strings.Join(q["connection"], ","); got != "5,7" {
+			t.Errorf("connection params: got %q, want %q", got, "5,7")
+		}
+		if got := strings.Join(q["userGroup"], ","); got != "devs" {
+			t.Errorf("userGroup params: got %q, want %q", got, "devs")
+		}
+		// Respond as a server that ignores the filter.
+		writeJSON(t, w, Permissions{
+			ConnectionPermissions: map[string][]string{
+				"5": {PermissionRead},
+				"6": {PermissionRead},
+				"7": {PermissionRead, PermissionUpdate},
+			},
+			UserPermissions:   map[string][]string{"bob": {PermissionRead}},
+			SystemPermissions: []string{SystemPermissionCreateUser},
+		})
+	})
+	got, err := c.GetSelfEffectivePermissionsFor(context.Background(), PermissionQuery{
+		Connections: []string{"5", "7"},
+		UserGroups:  []string{"devs"},
+	})
+	if err != nil {
+		t.Fatalf("GetSelfEffectivePermissionsFor: %v", err)
+	}
+	want := &Permissions{ConnectionPermissions: map[string][]string{
+		"5": {PermissionRead},
+		"7": {PermissionRead, PermissionUpdate},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("permissions: got %+v, want %+v", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"sort"
 )
 
 // ListSharingProfiles returns all sharing profiles visible to the authenticated
@@ -37,12 +38,16 @@ func (c *Client) GetSharingProfile(ctx context.Context, id string) (*SharingProf
 }
 
 // GetSharingProfileParameters returns the parameters for the sharing profile
-// with the given identifier (e.g. {"read-only": "true"}).
+// with the given identifier (e.g. {"read-only": "true"}). The map is never nil,
+// so callers may add to it directly.
 func (c *Client) GetSharingProfileParameters(ctx context.Context, id string) (map[string]string, error) {
 	var result map[string]string
 	if err := c.get(ctx, c.dataPath("sharingProfiles", id, "parameters"), &result); err != nil {
 		return nil, fmt.Errorf("guacamole: get sharing profile parameters %s: %w", id, err)
 	}
+	if result == nil {
+		result = map[string]string{}
+	}
 	return result, nil
 }
 
@@ -56,6 +61,40 @@ func (c *Client) UpdateSharingProfile(ctx context.Context, id string, profile Sh
 	return nil
 }
 
+// RenameSharingProfile changes the name of the sharing profile identified by
+// id, preserving its primary connection, parameters and attributes.
+func (c *Client) RenameSharingProfile(ctx context.Context, id, newName string) error {
+	err := c.modifySharingProfile(ctx, id, func(profile *SharingProfile) {
+		profile.Name = newName
+	})
+	if err != nil {
+		return fmt.Errorf("guacamole: rename sharing profile %s: %w", id, err)
+	}
+	return nil
+}
+
+// modifySharingProfile fetches the sharing profile identified by id together
+// with its parameters, applies fn, and PUTs the complete object back, since
+// UpdateSharingProfile replaces whatever is not re-sent. The Attributes map is
+// never nil when fn is called. Errors are returned as-is, like those of
+// modifyConnection.
+func (c *Client) modifySharingProfile(ctx context.Context, id string, fn func(*SharingProfile)) error {
+	profile, err := c.GetSharingProfile(ctx, id)
+	if err != nil {
+		return err
+	}
+	params, err := c.GetSharingProfileParameters(ctx, id)
+	if err != nil {
+		return err
+	}
+	if profile.Attributes == nil {
+		profile.Attributes = NullableStringMap{}
+	}
+	profile.Parameters = params
+	fn(profile)
+	return c.UpdateSharingProfile(ctx, id, *profile)
+}
+
 // DeleteSharingProfile permanently removes the sharing profile with the given
 // identifier.
 func (c *Client) DeleteSharingProfile(ctx context.Context, id string) error {
@@ -64,3 +103,96 @@ func (c *Client) DeleteSharingProfile(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// FindOrphanedSharingProfiles returns the sharing profiles whose
+// PrimaryConnectionIdentifier does not refer to any existing connection. Such
+// profiles are left behind when a connection is removed and only clutter the
+// UI. The result is sorted by identifier.
+func (c *Client) FindOrphanedSharingProfiles(ctx context.Context) ([]SharingProfile, error) {
+	profiles, err := c.ListSharingProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: find orphaned sharing profiles: %w", err)
+	}
+	conns, err := c.ListConnections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: find orphaned sharing profiles: %w", err)
+	}
+
+	var orphans []SharingProfile
+	for id, p := range profiles {
+		if _, ok := conns[p.PrimaryConnectionIdentifier]; ok {
+			continue
+		}
+		if p.Identifier == "" {
+			p.Identifier = id
+		}
+		orphans = append(orphans, p)
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].Identifier < orphans[j].Identifier
+	})
+	return orphans, nil
+}
+
+// CreateReadOnlySharingProfiles creates a read-only sharing profile
+// ({"read-only": "true"}) for each connection in connectionIDs, naming it by
+// formatting nameTemplate with the connection's name, e.g. "%s - view". Up to
+// 8 connections are processed concurrently. The returned slices are parallel
+// to connectionIDs: for each index exactly one of profiles[i] and errs[i] is
+// non-nil.
+//
+// nameTemplate must contain exactly one %s and no other verbs apart from %%.
+// Otherwise nothing is created and every errs[i] reports the invalid
+// template.
+func (c *Client) CreateReadOnlySharingProfiles(ctx context.Context, connectionIDs []string, nameTemplate string) ([]*SharingProfile, []error) {
+	profiles := make([]*SharingProfile, len(connectionIDs))
+	errs := make([]error, len(connectionIDs))
+	if err := checkNameTemplate(nameTemplate); err != nil {
+		for i := range errs {
+			errs[i] = fmt.Errorf("guacamole: create read-only sharing profiles: %w", err)
+		}
+		return profiles, errs
+	}
+	// Each goroutine writes only its own index, so no locking is needed.
+	forEachIndexBounded(len(connectionIDs), bulkWorkers, func(i int) {
+		id := connectionIDs[i]
+		conn, err := c.GetConnection(ctx, id)
+		if err == nil {
+			profiles[i], err = c.CreateSharingProfile(ctx, SharingProfile{
+				Name:                        fmt.Sprintf(nameTemplate, conn.Name),
+				PrimaryConnectionIdentifier: id,
+				Parameters:                  map[string]string{"read-only": "true"},
+			})
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("guacamole: create read-only sharing profile for connection %s: %w", id, err)
+		}
+	})
+	return profiles, errs
+}
+
+// checkNameTemplate verifies that tmpl has exactly one %s verb and no others
+// besides the literal %%, so that formatting it with one name never produces
+// fmt's %!(...) error text.
+func checkNameTemplate(tmpl string) error {
+	names := 0
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '%' {
+			continue
+		}
+		i++
+		switch {
+		case i == len(tmpl):
+			return fmt.Errorf("name template %q ends with a lone %%", tmpl)
+		case tmpl[i] == '%':
+		case tmpl[i] == 's':
+			names++
+		default:
+			return fmt.Errorf("name template %q contains verb %%%c; only %%s is allowed", tmpl, tmpl[i])
+		}
+	}
+	if names != 1 {
+		return fmt.Errorf("name template %q must contain exactly one %%s, found %d", tmpl, names)
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package guacamole
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,6 +88,26 @@ func TestGetSharingProfileParameters(t *testing.T) {
 	}
 }
 
+func TestGetSharingProfileParameters_empty_is_writable(t *testing.T) {
+	for _, body := range []string{"null", "{}"} {
+		t.Run(body, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertPath(t, r, "/api/session/data/postgresql/sharingProfiles/7/parameters")
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, body)
+			})
+			got, err := c.GetSharingProfileParameters(context.Background(), "7")
+			if err != nil {
+				t.Fatalf("GetSharingProfileParameters: %v", err)
+			}
+			if got == nil {
+				t.Fatal("parameters: got nil map, want empty map")
+			}
+			got["read-only"] = "true" // must not panic
+		})
+	}
+}
+
 func TestUpdateSharingProfile(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPut)
@@ -110,3 +133,126 @@ func TestDeleteSharingProfile(t *testing.T) {
 		t.Fatalf("DeleteSharingProfile: %v", err)
 	}
 }
+
+func TestFindOrphanedSharingProfiles(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/sharingProfiles":
+			writeJSON(t, w, map[string]SharingProfile{
+				"1": {Identifier: "1", Name: "live", PrimaryConnectionIdentifier: "5"},
+				"2": {Identifier: "2", Name: "stale", PrimaryConnectionIdentifier: "9"},
+			})
+		case "/api/session/data/postgresql/connections":
+			writeJSON(t, w, map[string]Connection{
+				"5": {Identifier: "5", Name: "ssh", Protocol: "ssh"},
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	orphans, err := c.FindOrphanedSharingProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("FindOrphanedSharingProfiles: %v", err)
+	}
+	if len(orphans) != 1 {
+		t.Fatalf("len: got %d, want 1", len(orphans))
+	}
+	if orphans[0].Identifier != "2" {
+		t.Errorf("Identifier: got %q, want %q", orphans[0].Identifier, "2")
+	}
+}
+
+func TestCreateReadOnlySharingProfiles(t *testing.T) {
+	names := map[string]string{"1": "web", "2": "db", "4": "jump"}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			id := strings.TrimPrefix(r.URL.Path, "/api/session/data/postgresql/connections/")
+			name, ok := names[id]
+			if !ok {
+				writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "no such connection")
+				return
+			}
+			writeJSON(t, w, Connection{Identifier: id, Name: name})
+		case http.MethodPost:
+			assertPath(t, r, "/api/session/data/postgresql/sharingProfiles")
+			var sp SharingProfile
+			mustReadJSON(t, r, &sp)
+			if sp.Parameters["read-only"] != "true" {
+				t.Errorf("parameters: got %v, want read-only", sp.Parameters)
+			}
+			sp.Identifier = "sp-" + sp.PrimaryConnectionIdentifier
+			writeJSON(t, w, sp)
+		}
+	})
+	ids := []string{"1", "2", "3", "4"}
+	profiles, errs := c.CreateReadOnlySharingProfiles(context.Background(), ids, "%s - view")
+	if len(profiles) != len(ids) || len(errs) != len(ids) {
+		t.Fatalf("lengths: got %d profiles, %d errors; want %d each", len(profiles), len(errs), len(ids))
+	}
+	for i, id := range ids {
+		if id == "3" {
+			if profiles[i] != nil || !IsNotFound(errs[i]) {
+				t.Errorf("connection 3: got %+v, %v; want nil profile and NOT_FOUND", profiles[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("connection %s: %v", id, errs[i])
+			continue
+		}
+		want := names[id] + " - view"
+		if profiles[i].Name != want || profiles[i].PrimaryConnectionIdentifier != id || profiles[i].Identifier != "sp-"+id {
+			t.Errorf("connection %s: got %+v, want name %q", id, profiles[i], want)
+		}
+	}
+}
+
+func TestRenameSharingProfile_changes_only_name(t *testing.T) {
+	current := SharingProfile{Identifier: "3", Name: "watch", PrimaryConnectionIdentifier: "42",
+		Attributes: NullableStringMap{"note": "helpdesk"}}
+	params := map[string]string{"read-only": "true"}
+	var put SharingProfile
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/sharingProfiles/3":
+			writeJSON(t, w, current)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/session/data/postgresql/sharingProfiles/3/parameters":
+			writeJSON(t, w, params)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/session/data/postgresql/sharingProfiles/3":
+			mustReadJSON(t, r, &put)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+	if err := c.RenameSharingProfile(context.Background(), "3", "observe"); err != nil {
+		t.Fatalf("RenameSharingProfile: %v", err)
+	}
+	want := current
+	want.Name = "observe"
+	want.Parameters = params
+	if !reflect.DeepEqual(put, want) {
+		t.Errorf("PUT body: got %+v, want %+v", put, want)
+	}
+}
+
+func TestCreateReadOnlySharingProfiles_invalid_template(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s for an invalid template", r.Method, r.URL.Path)
+	})
+	for _, tmpl := range []string{"view", "%s - %s", "%d view", "%q view", "view %", "%-10s"} {
+		t.Run(tmpl, func(t *testing.T) {
+			profiles, errs := c.CreateReadOnlySharingProfiles(context.Background(), []string{"1", "2"}, tmpl)
+			for i := range errs {
+				if errs[i] == nil || profiles[i] != nil {
+					t.Errorf("index %d: got %v, %v; want template error", i, profiles[i], errs[i])
+				}
+			}
+		})
+	}
+	if err := checkNameTemplate("100%% %s"); err != nil {
+		t.Errorf("checkNameTemplate with %%%%: %v", err)
+	}
+}
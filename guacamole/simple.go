@@ -0,0 +1,188 @@
+package guacamole
+
+import "context"
+
+// SimpleClient exposes the most common Client operations without a context
+// parameter, for scripts that have no deadline or cancellation to propagate.
+// Each method calls the Client method of the same name with
+// context.Background(); per-category timeouts configured with WithTimeouts
+// still apply. Obtain one with Client.Simple.
+type SimpleClient struct {
+	c *Client
+}
+
+// Simple returns a SimpleClient that shares c's configuration and session.
+// Authenticating through either one authenticates both.
+func (c *Client) Simple() *SimpleClient {
+	return &SimpleClient{c: c}
+}
+
+// Client returns the underlying context-aware Client.
+func (s *SimpleClient) Client() *Client { return s.c }
+
+// Authenticate calls Client.Authenticate with a background context.
+func (s *SimpleClient) Authenticate(username, password string) error {
+	return s.c.Authenticate(context.Background(), username, password)
+}
+
+// Logout calls Client.Logout with a background context.
+func (s *SimpleClient) Logout() error {
+	return s.c.Logout(context.Background())
+}
+
+// GetSelf calls Client.GetSelf with a background context.
+func (s *SimpleClient) GetSelf() (*Self, error) {
+	return s.c.GetSelf(context.Background())
+}
+
+// ListUsers calls Client.ListUsers with a background context.
+func (s *SimpleClient) ListUsers() (map[string]User, error) {
+	return s.c.ListUsers(context.Background())
+}
+
+// GetUser calls Client.GetUser with a background context.
+func (s *SimpleClient) GetUser(username string) (*User, error) {
+	return s.c.GetUser(context.Background(), username)
+}
+
+// CreateUser calls Client.CreateUser with a background context.
+func (s *SimpleClient) CreateUser(user User) (*User, error) {
+	return s.c.CreateUser(context.Background(), user)
+}
+
+// UpdateUser calls Client.UpdateUser with a background context.
+func (s *SimpleClient) UpdateUser(username string, user User) error {
+	return s.c.UpdateUser(context.Background(), username, user)
+}
+
+// DeleteUser calls Client.DeleteUser with a background context.
+func (s *SimpleClient) DeleteUser(username string) error {
+	return s.c.DeleteUser(context.Background(), username)
+}
+
+// ListUserGroups calls Client.ListUserGroups with a background context.
+func (s *SimpleClient) ListUserGroups() (map[string]UserGroup, error) {
+	return s.c.ListUserGroups(context.Background())
+}
+
+// GetUserGroup calls Client.GetUserGroup with a background context.
+func (s *SimpleClient) GetUserGroup(id string) (*UserGroup, error) {
+	return s.c.GetUserGroup(context.Background(), id)
+}
+
+// CreateUserGroup calls Client.CreateUserGroup with a background context.
+func (s *SimpleClient) CreateUserGroup(group UserGroup) (*UserGroup, error) {
+	return s.c.CreateUserGroup(context.Background(), group)
+}
+
+// UpdateUserGroup calls Client.UpdateUserGroup with a background context.
+func (s *SimpleClient) UpdateUserGroup(id string, group UserGroup) error {
+	return s.c.UpdateUserGroup(context.Background(), id, group)
+}
+
+// DeleteUserGroup calls Client.DeleteUserGroup with a background context.
+func (s *SimpleClient) DeleteUserGroup(id string) error {
+	return s.c.DeleteUserGroup(context.Background(), id)
+}
+
+// ListConnections calls Client.ListConnections with a background context.
+func (s *SimpleClient) ListConnections() (map[string]Connection, error) {
+	return s.c.ListConnections(context.Background())
+}
+
+// GetConnection calls Client.GetConnection with a background context.
+func (s *SimpleClient) GetConnection(id string) (*Connection, error) {
+	return s.c.GetConnection(context.Background(), id)
+}
+
+// GetConnectionParameters calls Client.GetConnectionParameters with a
+// background context.
+func (s *SimpleClient) GetConnectionParameters(id string) (map[string]string, error) {
+	return s.c.GetConnectionParameters(context.Background(), id)
+}
+
+// CreateConnection calls Client.CreateConnection with a background context.
+func (s *SimpleClient) CreateConnection(conn Connection) (*Connection, error) {
+	return s.c.CreateConnection(context.Background(), conn)
+}
+
+// UpdateConnection calls Client.UpdateConnection with a background context.
+func (s *SimpleClient) UpdateConnection(id string, conn Connection) error {
+	return s.c.UpdateConnection(context.Background(), id, conn)
+}
+
+// DeleteConnection calls Client.DeleteConnection with a background context.
+func (s *SimpleClient) DeleteConnection(id string) error {
+	return s.c.DeleteConnection(context.Background(), id)
+}
+
+// ListConnectionGroups calls Client.ListConnectionGroups with a background
+// context.
+func (s *SimpleClient) ListConnectionGroups() (map[string]ConnectionGroup, error) {
+	return s.c.ListConnectionGroups(context.Background())
+}
+
+// GetConnectionGroup calls Client.GetConnectionGroup with a background
+// context.
+func (s *SimpleClient) GetConnectionGroup(id string) (*ConnectionGroup, error) {
+	return s.c.GetConnectionGroup(context.Background(), id)
+}
+
+// GetConnectionGroupTree calls Client.GetConnectionGroupTree with a
+// background context.
+func (s *SimpleClient) GetConnectionGroupTree(rootID string) (*ConnectionGroup, error) {
+	return s.c.GetConnectionGroupTree(context.Background(), rootID)
+}
+
+// CreateConnectionGroup calls Client.CreateConnectionGroup with a background
+// context.
+func (s *SimpleClient) CreateConnectionGroup(group ConnectionGroup) (*ConnectionGroup, error) {
+	return s.c.CreateConnectionGroup(context.Background(), group)
+}
+
+// UpdateConnectionGroup calls Client.UpdateConnectionGroup with a background
+// context.
+func (s *SimpleClient) UpdateConnectionGroup(id string, group ConnectionGroup) error {
+	return s.c.UpdateConnectionGroup(context.Background(), id, group)
+}
+
+// DeleteConnectionGroup calls Client.DeleteConnectionGroup with a background
+// context.
+func (s *SimpleClient) DeleteConnectionGroup(id string) error {
+	return s.c.DeleteConnectionGroup(context.Background(), id)
+}
+
+// ListSharingProfiles calls Client.ListSharingProfiles with a background
+// context.
+func (s *SimpleClient) ListSharingProfiles() (map[string]SharingProfile, error) {
+	return s.c.ListSharingProfiles(context.Background())
+}
+
+// GetSharingProfile calls Client.GetSharingProfile with a background context.
+func (s *SimpleClient) GetSharingProfile(id string) (*SharingProfile, error) {
+	return s.c.GetSharingProfile(context.Background(), id)
+}
+
+// CreateSharingProfile calls Client.CreateSharingProfile with a background
+// context.
+func (s *SimpleClient) CreateSharingProfile(profile SharingProfile) (*SharingProfile, error) {
+	return s.c.CreateSharingProfile(context.Background(), profile)
+}
+
+// UpdateSharingProfile calls Client.UpdateSharingProfile with a background
+// context.
+func (s *SimpleClient) UpdateSharingProfile(id string, profile SharingProfile) error {
+	return s.c.UpdateSharingProfile(context.Background(), id, profile)
+}
+
+// DeleteSharingProfile calls Client.DeleteSharingProfile with a background
+// context.
+func (s *SimpleClient) DeleteSharingProfile(id string) error {
+	return s.c.DeleteSharingProfile(context.Background(), id)
+}
+
+// ListActiveConnections calls Client.ListActiveConnections with a background
+// context.
+func (s *SimpleClient) ListActiveConnections() (map[string]ActiveConnection, error) {
+	return s.c.ListActiveConnections(context.Background())
+}
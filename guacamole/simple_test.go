@@ -0,0 +1,76 @@
+package guacamole
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSimple_delegates_with_background_context(t *testing.T) {
+	var calls []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Path == "/api/session/data/postgresql/users/alice" {
+				writeJSON(t, w, User{Username: "alice"})
+				return
+			}
+			writeJSON(t, w, map[string]Connection{"1": {Identifier: "1", Name: "web"}})
+		case http.MethodPost:
+			var conn Connection
+			mustReadJSON(t, r, &conn)
+			conn.Identifier = "2"
+			writeJSON(t, w, conn)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	s := c.Simple()
+	if s.Client() != c {
+		t.Fatal("Client: does not return the wrapped client")
+	}
+
+	u, err := s.GetUser("alice")
+	if err != nil || u.Username != "alice" {
+		t.Fatalf("GetUser: got %+v, %v", u, err)
+	}
+	conns, err := s.ListConnections()
+	if err != nil || conns["1"].Name != "web" {
+		t.Fatalf("ListConnections: got %+v, %v", conns, err)
+	}
+	created, err := s.CreateConnection(Connection{Name: "db", Protocol: "ssh"})
+	if err != nil || created.Identifier != "2" {
+		t.Fatalf("CreateConnection: got %+v, %v", created, err)
+	}
+	if err := s.DeleteConnection("2"); err != nil {
+		t.Fatalf("DeleteConnection: %v", err)
+	}
+
+	want := []string{
+		"GET /api/session/data/postgresql/users/alice",
+		"GET /api/session/data/postgresql/connections",
+		"POST /api/session/data/postgresql/connections",
+		"DELETE /api/session/data/postgresql/connections/2",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls: got %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: got %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestSimple_shares_session(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, AuthResponse{AuthToken: "new-token", DataSource: "mysql", Username: "admin"})
+	})
+	c.authToken = ""
+	if err := c.Simple().Authenticate("admin", "secret"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if c.authToken != "new-token" || c.dataSource != "mysql" {
+		t.Errorf("client session: got token %q data source %q", c.authToken, c.dataSource)
+	}
+}
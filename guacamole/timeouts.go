@@ -0,0 +1,79 @@
+package guacamole
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Timeouts sets request deadlines per operation category. It is applied with
+// WithTimeouts. A deadline is only derived when the caller's context does not
+// already carry one, so an explicit context deadline always wins.
+type Timeouts struct {
+	// Read bounds GET requests, including slow tree and history reads.
+	Read time.Duration
+	// Write bounds POST, PUT, PATCH and DELETE requests.
+	Write time.Duration
+	// Auth bounds the token exchange performed by Authenticate.
+	Auth time.Duration
+}
+
+// timeoutCategory selects which Timeouts field governs a request.
+type timeoutCategory int
+
+const (
+	timeoutRead timeoutCategory = iota
+	timeoutWrite
+	timeoutAuth
+)
+
+// WithTimeouts applies per-category deadlines to requests whose context has no
+// deadline of its own. Because these deadlines replace the single client-wide
+// timeout, the http.Client's Timeout is cleared (on a copy, never on a
+// caller-supplied client). A zero field means requests in that category are
+// bounded only by their context.
+func WithTimeouts(t Timeouts) Option {
+	return func(c *Client) {
+		hc := c.cloneHTTPClient()
+		hc.Timeout = 0
+		c.httpClient = hc
+		c.timeouts = &t
+	}
+}
+
+// withCategoryTimeout derives a context with the configured deadline for
+// category, unless no timeouts are configured or ctx already has a deadline.
+// The returned cancel func must always be called.
+func (c *Client) withCategoryTimeout(ctx context.Context, category timeoutCategory) (context.Context, context.CancelFunc) {
+	if c.timeouts == nil {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	var d time.Duration
+	switch category {
+	case timeoutRead:
+		d = c.timeouts.Read
+	case timeoutWrite:
+		d = c.timeouts.Write
+	case timeoutAuth:
+		d = c.timeouts.Auth
+	}
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// cancelOnClose releases a request context when its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
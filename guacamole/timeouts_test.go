@@ -0,0 +1,59 @@
+package guacamole
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithTimeouts_read_not_capped_by_write_timeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		writeJSON(t, w, ConnectionGroup{Identifier: "ROOT"})
+	})
+	WithTimeouts(Timeouts{Read: 5 * time.Second, Write: 10 * time.Millisecond})(c)
+
+	if _, err := c.GetConnectionGroupTree(context.Background(), RootConnectionGroupIdentifier); err != nil {
+		t.Fatalf("GetConnectionGroupTree: %v", err)
+	}
+}
+
+func TestWithTimeouts_write_deadline_applied(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	WithTimeouts(Timeouts{Read: 5 * time.Second, Write: 20 * time.Millisecond})(c)
+
+	err := c.DeleteConnection(context.Background(), "1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWithTimeouts_caller_deadline_wins(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	WithTimeouts(Timeouts{Write: 10 * time.Millisecond})(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.DeleteConnection(ctx, "1"); err != nil {
+		t.Fatalf("DeleteConnection: %v", err)
+	}
+}
+
+func TestWithTimeouts_does_not_mutate_supplied_client(t *testing.T) {
+	hc := &http.Client{Timeout: 30 * time.Second}
+	NewClientWithHTTPClient("http://localhost", hc, WithTimeouts(Timeouts{Read: time.Second}))
+	if hc.Timeout != 30*time.Second {
+		t.Errorf("supplied client Timeout: got %v, want 30s", hc.Timeout)
+	}
+}
@@ -0,0 +1,259 @@
+package guacamole
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Topology is a self-contained snapshot of the connection hierarchy for
+// backup and restore: every connection group, connection (with parameters)
+// and sharing profile (with parameters) beneath ROOT. It carries no
+// identifiers, so importing it always creates fresh objects. Topology is
+// designed to be serialised with encoding/json.
+type Topology struct {
+	Groups      []TopologyGroup      `json:"groups,omitempty"`
+	Connections []TopologyConnection `json:"connections,omitempty"`
+}
+
+// TopologyGroup is a connection group within a Topology.
+type TopologyGroup struct {
+	Name        string               `json:"name"`
+	Type        string               `json:"type"`
+	Attributes  NullableStringMap    `json:"attributes"`
+	Groups      []TopologyGroup      `json:"groups,omitempty"`
+	Connections []TopologyConnection `json:"connections,omitempty"`
+}
+
+// TopologyConnection is a connection within a Topology.
+type TopologyConnection struct {
+	Name            string                   `json:"name"`
+	Protocol        string                   `json:"protocol"`
+	Parameters      map[string]string        `json:"parameters"`
+	Attributes      NullableStringMap        `json:"attributes"`
+	SharingProfiles []TopologySharingProfile `json:"sharingProfiles,omitempty"`
+}
+
+// TopologySharingProfile is a sharing profile attached to a
+// TopologyConnection.
+type TopologySharingProfile struct {
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters"`
+	Attributes NullableStringMap `json:"attributes"`
+}
+
+// ExportOptions controls ExportTopologyWithOptions.
+type ExportOptions struct {
+	// Workers bounds the number of parameter requests in flight at once.
+	// Zero or negative means the package default of 8.
+	Workers int
+
+	// FailFast aborts the export at the first failed parameter request. By
+	// default such failures are recorded and the export continues.
+	FailFast bool
+}
+
+// ExportTopology reads the complete connection hierarchy, including the
+// parameters of every connection and sharing profile, into a Topology.
+// Siblings are ordered by name so exports of an unchanged server are
+// identical. The result contains credentials stored in parameters and should
+// be protected accordingly. It is ExportTopologyWithOptions with default
+// workers and FailFast set.
+func (c *Client) ExportTopology(ctx context.Context) (*Topology, error) {
+	return c.ExportTopologyWithOptions(ctx, ExportOptions{FailFast: true})
+}
+
+// ExportTopologyWithOptions is like ExportTopology but fetches parameters
+// concurrently with at most opts.Workers requests in flight; the output order
+// does not depend on the order in which requests complete.
+//
+// Unless opts.FailFast is set, a failed parameter request does not abort the
+// export: the Topology is still returned, complete except that the affected
+// connections and sharing profiles have nil Parameters, together with an
+// error joining every failure. With FailFast the first failure cancels the
+// remaining requests and no Topology is returned.
+func (c *Client) ExportTopologyWithOptions(ctx context.Context, opts ExportOptions) (*Topology, error) {
+	tree, err := c.GetConnectionGroupTree(ctx, RootConnectionGroupIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: export topology: %w", err)
+	}
+	profiles, err := c.ListSharingProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("guacamole: export topology: %w", err)
+	}
+	byConnection := make(map[string][]SharingProfile)
+	for id, p := range profiles {
+		if p.Identifier == "" {
+			p.Identifier = id
+		}
+		byConnection[p.PrimaryConnectionIdentifier] = append(byConnection[p.PrimaryConnectionIdentifier], p)
+	}
+
+	params, errs := c.exportParameters(ctx, tree, byConnection, opts)
+	if len(errs) > 0 && opts.FailFast {
+		return nil, fmt.Errorf("guacamole: export topology: %w", joinErrors(errs))
+	}
+	root := exportGroup(tree, byConnection, params)
+	t := &Topology{Groups: root.Groups, Connections: root.Connections}
+	if len(errs) > 0 {
+		return t, fmt.Errorf("guacamole: export topology: %w", joinErrors(errs))
+	}
+	return t, nil
+}
+
+// Keys of the parameter maps collected by exportParameters.
+const (
+	exportConnectionKey     = "connection "
+	exportSharingProfileKey = "sharing profile "
+)
+
+// exportParameters fetches the parameters of every connection in tree and of
+// its sharing profiles in byConnection, keyed by exportConnectionKey or
+// exportSharingProfileKey followed by the identifier. Failures are returned
+// under the same keys; with opts.FailFast only the first one is kept and
+// requests not yet started are skipped.
+func (c *Client) exportParameters(ctx context.Context, tree *ConnectionGroup, byConnection map[string][]SharingProfile,
+	opts ExportOptions) (map[string]map[string]string, map[string]error) {
+	var keys []string
+	tree.Walk(nil, func(conn *Connection) {
+		keys = append(keys, exportConnectionKey+conn.Identifier)
+		for _, p := range byConnection[conn.Identifier] {
+			keys = append(keys, exportSharingProfileKey+p.Identifier)
+		}
+	})
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = bulkWorkers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	params := make(map[string]map[string]string, len(keys))
+	errs := make(map[string]error)
+	forEachBounded(keys, workers, func(key string) {
+		if ctx.Err() != nil && opts.FailFast {
+			return
+		}
+		var p map[string]string
+		var err error
+		if id, ok := strings.CutPrefix(key, exportConnectionKey); ok {
+			p, err = c.GetConnectionParameters(ctx, id)
+		} else {
+			p, err = c.GetSharingProfileParameters(ctx, strings.TrimPrefix(key, exportSharingProfileKey))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			params[key] = p
+		case !opts.FailFast:
+			errs[key] = err
+		case len(errs) == 0:
+			errs[key] = err
+			cancel()
+		}
+	})
+	return params, errs
+}
+
+// exportGroup converts g and its descendants using the sharing profiles in
+// byConnection and the parameters collected by exportParameters.
+func exportGroup(g *ConnectionGroup, byConnection map[string][]SharingProfile, params map[string]map[string]string) TopologyGroup {
+	out := TopologyGroup{Name: g.Name, Type: g.Type, Attributes: g.Attributes}
+
+	conns := append([]Connection(nil), g.ChildConnections...)
+	sortConnections(conns)
+	for _, conn := range conns {
+		tc := TopologyConnection{
+			Name:       conn.Name,
+			Protocol:   conn.Protocol,
+			Parameters: params[exportConnectionKey+conn.Identifier],
+			Attributes: conn.Attributes,
+		}
+		profiles := byConnection[conn.Identifier]
+		sort.Slice(profiles, func(i, j int) bool {
+			return lessByNameThenID(profiles[i].Name, profiles[i].Identifier, profiles[j].Name, profiles[j].Identifier)
+		})
+		for _, p := range profiles {
+			tc.SharingProfiles = append(tc.SharingProfiles, TopologySharingProfile{
+				Name:       p.Name,
+				Parameters: params[exportSharingProfileKey+p.Identifier],
+				Attributes: p.Attributes,
+			})
+		}
+		out.Connections = append(out.Connections, tc)
+	}
+
+	groups := append([]ConnectionGroup(nil), g.ChildConnectionGroups...)
+	sortConnectionGroups(groups)
+	for i := range groups {
+		out.Groups = append(out.Groups, exportGroup(&groups[i], byConnection, params))
+	}
+	return out
+}
+
+// ImportOptions controls ImportTopology.
+type ImportOptions struct {
+	// ParentIdentifier is the connection group the topology's top-level
+	// groups and connections are created in. Empty means ROOT.
+	ParentIdentifier string
+}
+
+// ImportTopology recreates t beneath opts.ParentIdentifier, creating every
+// group, connection and sharing profile afresh with server-assigned
+// identifiers. Existing objects are never modified, so importing into a
+// populated server may produce same-named siblings. Import stops at the first
+// failure and leaves already-created objects in place.
+func (c *Client) ImportTopology(ctx context.Context, t *Topology, opts ImportOptions) error {
+	root := TopologyGroup{Groups: t.Groups, Connections: t.Connections}
+	if err := c.importChildren(ctx, &root, parentOrRoot(opts.ParentIdentifier)); err != nil {
+		return fmt.Errorf("guacamole: import topology: %w", err)
+	}
+	return nil
+}
+
+// importChildren creates the connections and groups of g inside parentID.
+func (c *Client) importChildren(ctx context.Context, g *TopologyGroup, parentID string) error {
+	for _, tc := range g.Connections {
+		conn, err := c.CreateConnection(ctx, Connection{
+			Name:             tc.Name,
+			ParentIdentifier: parentID,
+			Protocol:         tc.Protocol,
+			Parameters:       tc.Parameters,
+			Attributes:       tc.Attributes,
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range tc.SharingProfiles {
+			if _, err := c.CreateSharingProfile(ctx, SharingProfile{
+				Name:                        p.Name,
+				PrimaryConnectionIdentifier: conn.Identifier,
+				Parameters:                  p.Parameters,
+				Attributes:                  p.Attributes,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range g.Groups {
+		child := &g.Groups[i]
+		created, err := c.CreateConnectionGroup(ctx, ConnectionGroup{
+			Name:             child.Name,
+			ParentIdentifier: parentID,
+			Type:             child.Type,
+			Attributes:       child.Attributes,
+		})
+		if err != nil {
+			return err
+		}
+		if err := c.importChildren(ctx, child, created.Identifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
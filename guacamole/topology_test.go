@@ -0,0 +1,256 @@
+package guacamole
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTopologyServer is an in-memory store serving the endpoints used by
+// ExportTopology and ImportTopology.
+type fakeTopologyServer struct {
+	mu       sync.Mutex
+	nextID   int
+	groups   map[string]ConnectionGroup
+	conns    map[string]Connection
+	profiles map[string]SharingProfile
+}
+
+func newFakeTopologyServer() *fakeTopologyServer {
+	return &fakeTopologyServer{
+		groups:   map[string]ConnectionGroup{},
+		conns:    map[string]Connection{},
+		profiles: map[string]SharingProfile{},
+	}
+}
+
+func (s *fakeTopologyServer) id() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// tree assembles the group identified by id with all of its descendants.
+func (s *fakeTopologyServer) tree(id string) ConnectionGroup {
+	g := s.groups[id]
+	if id == RootConnectionGroupIdentifier {
+		g = ConnectionGroup{Identifier: id, Name: "ROOT", Type: ConnectionGroupTypeOrganizational}
+	}
+	for _, conn := range s.conns {
+		if conn.ParentIdentifier == id {
+			conn.Parameters = nil
+			g.ChildConnections = append(g.ChildConnections, conn)
+		}
+	}
+	for childID, child := range s.groups {
+		if child.ParentIdentifier == id {
+			g.ChildConnectionGroups = append(g.ChildConnectionGroups, s.tree(childID))
+		}
+	}
+	return g
+}
+
+func (s *fakeTopologyServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		p := strings.TrimPrefix(r.URL.Path, "/api/session/data/postgresql/")
+		switch {
+		case r.Method == http.MethodGet && p == "connectionGroups/ROOT/tree":
+			writeJSON(t, w, s.tree(RootConnectionGroupIdentifier))
+		case r.Method == http.MethodGet && p == "sharingProfiles":
+			out := map[string]SharingProfile{}
+			for id, sp := range s.profiles {
+				sp.Parameters = nil
+				out[id] = sp
+			}
+			writeJSON(t, w, out)
+		case r.Method == http.MethodGet && strings.HasPrefix(p, "connections/"):
+			writeJSON(t, w, s.conns[strings.Split(p, "/")[1]].Parameters)
+		case r.Method == http.MethodGet && strings.HasPrefix(p, "sharingProfiles/"):
+			writeJSON(t, w, s.profiles[strings.Split(p, "/")[1]].Parameters)
+		case r.Method == http.MethodPost && p == "connectionGroups":
+			var g ConnectionGroup
+			mustReadJSON(t, r, &g)
+			g.Identifier = s.id()
+			s.groups[g.Identifier] = g
+			writeJSON(t, w, g)
+		case r.Method == http.MethodPost && p == "connections":
+			var conn Connection
+			mustReadJSON(t, r, &conn)
+			conn.Identifier = s.id()
+			s.conns[conn.Identifier] = conn
+			writeJSON(t, w, conn)
+		case r.Method == http.MethodPost && p == "sharingProfiles":
+			var sp SharingProfile
+			mustReadJSON(t, r, &sp)
+			sp.Identifier = s.id()
+			s.profiles[sp.Identifier] = sp
+			writeJSON(t, w, sp)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}
+}
+
+// sampleTopology is a small topology in export order (siblings sorted by
+// name) with every map non-nil, as it looks after a JSON round trip.
+var sampleTopology = Topology{
+	Connections: []TopologyConnection{{
+		Name:       "bastion",
+		Protocol:   "ssh",
+		Parameters: map[string]string{"hostname": "bastion.example", "port": "22"},
+		Attributes: NullableStringMap{},
+		SharingProfiles: []TopologySharingProfile{{
+			Name:       "watch",
+			Parameters: map[string]string{"read-only": "true"},
+			Attributes: NullableStringMap{},
+		}},
+	}},
+	Groups: []TopologyGroup{{
+		Name:       "Lab",
+		Type:       ConnectionGroupTypeOrganizational,
+		Attributes: NullableStringMap{"max-connections": "10"},
+		Connections: []TopologyConnection{
+			{Name: "a-desktop", Protocol: "rdp", Parameters: map[string]string{"hostname": "a.lab"}, Attributes: NullableStringMap{}},
+			{Name: "b-desktop", Protocol: "rdp", Parameters: map[string]string{"hostname": "b.lab"}, Attributes: NullableStringMap{}},
+		},
+		Groups: []TopologyGroup{{
+			Name:       "Pool",
+			Type:       ConnectionGroupTypeBalancing,
+			Attributes: NullableStringMap{},
+			Connections: []TopologyConnection{
+				{Name: "node", Protocol: "vnc", Parameters: map[string]string{"hostname": "node.lab"}, Attributes: NullableStringMap{}},
+			},
+		}},
+	}},
+}
+
+func TestTopology_round_trip(t *testing.T) {
+	srv := newFakeTopologyServer()
+	c := newTestClient(t, srv.handle(t))
+
+	if err := c.ImportTopology(context.Background(), &sampleTopology, ImportOptions{}); err != nil {
+		t.Fatalf("ImportTopology: %v", err)
+	}
+	got, err := c.ExportTopology(context.Background())
+	if err != nil {
+		t.Fatalf("ExportTopology: %v", err)
+	}
+	if !reflect.DeepEqual(*got, sampleTopology) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(sampleTopology, "", "  ")
+		t.Errorf("exported topology mismatch:\ngot  %s\nwant %s", gotJSON, wantJSON)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if strings.Contains(string(data), "identifier") {
+		t.Errorf("exported JSON contains identifiers: %s", data)
+	}
+}
+
+func TestImportTopology_parent(t *testing.T) {
+	srv := newFakeTopologyServer()
+	c := newTestClient(t, srv.handle(t))
+	topo := Topology{Connections: []TopologyConnection{{Name: "x", Protocol: "ssh"}}}
+	if err := c.ImportTopology(context.Background(), &topo, ImportOptions{ParentIdentifier: "42"}); err != nil {
+		t.Fatalf("ImportTopology: %v", err)
+	}
+	for _, conn := range srv.conns {
+		if conn.ParentIdentifier != "42" {
+			t.Errorf("parent: got %q, want %q", conn.ParentIdentifier, "42")
+		}
+	}
+}
+
+// isParameterFetch reports whether r reads connection or sharing profile
+// parameters.
+func isParameterFetch(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/parameters")
+}
+
+func TestExportTopologyWithOptions_bounded_and_ordered(t *testing.T) {
+	srv := newFakeTopologyServer()
+	inner := srv.handle(t)
+	var inFlight, maxInFlight int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if isParameterFetch(r) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		inner(w, r)
+	})
+	if err := c.ImportTopology(context.Background(), &sampleTopology, ImportOptions{}); err != nil {
+		t.Fatalf("ImportTopology: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := c.ExportTopologyWithOptions(context.Background(), ExportOptions{Workers: 2})
+		if err != nil {
+			t.Fatalf("ExportTopologyWithOptions: %v", err)
+		}
+		if !reflect.DeepEqual(*got, sampleTopology) {
+			t.Fatalf("export %d: topology differs from the imported one", i)
+		}
+	}
+	if m := atomic.LoadInt32(&maxInFlight); m > 2 {
+		t.Errorf("max parameter requests in flight: got %d, want <= 2", m)
+	}
+}
+
+func TestExportTopologyWithOptions_errors(t *testing.T) {
+	srv := newFakeTopologyServer()
+	inner := srv.handle(t)
+	var failing string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if isParameterFetch(r) && failing != "" && strings.Contains(r.URL.Path, "/connections/"+failing+"/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		inner(w, r)
+	})
+	if err := c.ImportTopology(context.Background(), &sampleTopology, ImportOptions{}); err != nil {
+		t.Fatalf("ImportTopology: %v", err)
+	}
+	for id, conn := range srv.conns {
+		if conn.Name == "a-desktop" {
+			failing = id
+		}
+	}
+
+	got, err := c.ExportTopologyWithOptions(context.Background(), ExportOptions{})
+	if err == nil {
+		t.Fatal("expected recorded error, got nil")
+	}
+	if got == nil {
+		t.Fatal("expected partial topology alongside recorded error")
+	}
+	lab := got.Groups[0]
+	if lab.Connections[0].Name != "a-desktop" || lab.Connections[0].Parameters != nil {
+		t.Errorf("failed connection: got %+v, want a-desktop with nil parameters", lab.Connections[0])
+	}
+	if lab.Connections[1].Parameters["hostname"] != "b.lab" {
+		t.Errorf("b-desktop parameters: got %v", lab.Connections[1].Parameters)
+	}
+
+	got, err = c.ExportTopologyWithOptions(context.Background(), ExportOptions{FailFast: true})
+	if err == nil || got != nil {
+		t.Errorf("FailFast: got %v, %v; want nil topology and error", got, err)
+	}
+}
@@ -58,6 +58,10 @@ type AuthResponse struct {
 // etc.) and is only populated when explicitly requested via the /parameters
 // endpoint. On create/update, set Parameters to supply these values; on read,
 // call GetConnectionParameters separately.
+//
+// ParentIdentifier is omitted from JSON when empty, so clearing it does not
+// move a connection to the top level; set it to RootConnectionGroupIdentifier
+// (or use MoveConnectionToRoot) instead.
 type Connection struct {
 	Identifier        string            `json:"identifier,omitempty"`
 	Name              string            `json:"name"`
@@ -97,12 +101,36 @@ type User struct {
 }
 
 // UserGroup represents a Guacamole user group.
+//
+// The database backends expose whether a group is disabled both as the
+// Disabled field and as the "disabled" attribute. Groups read through
+// GetUserGroup and ListUserGroups have the two reconciled: the group is
+// disabled if either says so, and a present attribute is rewritten to agree.
 type UserGroup struct {
 	Identifier string            `json:"identifier"`
 	Disabled   bool              `json:"disabled,omitempty"`
 	Attributes NullableStringMap `json:"attributes"`
 }
 
+// UserGroupAttributeDisabled is the attribute through which the database
+// backends report and accept a group's disabled state, "true" or "".
+const UserGroupAttributeDisabled = "disabled"
+
+// reconcileDisabled makes the Disabled field and the disabled attribute of g
+// agree, treating the group as disabled if either one says so. The attribute
+// is only rewritten when present.
+func (g *UserGroup) reconcileDisabled() {
+	v, ok := g.Attributes[UserGroupAttributeDisabled]
+	if !ok {
+		return
+	}
+	g.Disabled = g.Disabled || v == "true"
+	g.Attributes[UserGroupAttributeDisabled] = ""
+	if g.Disabled {
+		g.Attributes[UserGroupAttributeDisabled] = "true"
+	}
+}
+
 // SharingProfile represents a sharing profile attached to a connection. It
 // defines a secondary set of connection parameters used when sharing a
 // session, most commonly {"read-only": "true"}.
@@ -0,0 +1,273 @@
+package guacamole
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// User attribute keys understood by the standard Guacamole database
+// authentication backends.
+const (
+	UserAttributeFullName         = "guac-full-name"
+	UserAttributeEmailAddress     = "guac-email-address"
+	UserAttributeOrganization     = "guac-organization"
+	UserAttributeOrganizationRole = "guac-organizational-role"
+)
+
+// UserAttributeLanguage holds the user's preferred UI language. It is not
+// part of the stock database schema, so it only persists with backends or
+// extensions that store arbitrary user attributes.
+const UserAttributeLanguage = "guac-user-language"
+
+// languageCodePattern matches language codes such as "en" or "en_US".
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(_[A-Z]{2})?$`)
+
+// Email returns the user's email address attribute, or "" if unset.
+func (u *User) Email() string {
+	return u.Attributes[UserAttributeEmailAddress]
+}
+
+// SetEmail validates addr as an RFC 5322 address and stores it in the
+// guac-email-address attribute. Only the bare address is stored, so
+// "Alice <alice@example.com>" is saved as "alice@example.com". Passing an
+// empty string clears the attribute. On a validation error the attributes are
+// left untouched.
+func (u *User) SetEmail(addr string) error {
+	if addr == "" {
+		u.setAttribute(UserAttributeEmailAddress, "")
+		return nil
+	}
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return fmt.Errorf("guacamole: invalid email address %q: %w", addr, err)
+	}
+	u.setAttribute(UserAttributeEmailAddress, parsed.Address)
+	return nil
+}
+
+// Language returns the user's preferred language code, or "" if unset.
+func (u *User) Language() string {
+	return u.Attributes[UserAttributeLanguage]
+}
+
+// SetLanguage stores code in the guac-user-language attribute. code must be
+// a lowercase language code optionally followed by an underscore and an
+// uppercase region, such as "de" or "pt_BR". Passing an empty string clears
+// the attribute. On a validation error the attributes are left untouched.
+func (u *User) SetLanguage(code string) error {
+	if code != "" && !languageCodePattern.MatchString(code) {
+		return fmt.Errorf("guacamole: invalid language code %q", code)
+	}
+	u.setAttribute(UserAttributeLanguage, code)
+	return nil
+}
+
+// UserAttributeExpired marks the user's password as expired, so the user
+// must choose a new one at the next login. It is "true" when set.
+const UserAttributeExpired = "expired"
+
+// PasswordExpired reports whether the user must change their password at the
+// next login.
+func (u *User) PasswordExpired() bool {
+	return u.Attributes[UserAttributeExpired] == "true"
+}
+
+// SetPasswordExpired sets or clears the expired attribute.
+func (u *User) SetPasswordExpired(expired bool) {
+	value := ""
+	if expired {
+		value = "true"
+	}
+	u.setAttribute(UserAttributeExpired, value)
+}
+
+// UserAttributeDisabled mirrors the User.Disabled field in the attribute form
+// the database backends also accept. It is "true" when set.
+const UserAttributeDisabled = "disabled"
+
+// User restriction attribute keys. The access window limits login to a time
+// of day and the validity period to a range of dates, both interpreted in the
+// timezone attribute (or the server's zone when unset).
+const (
+	UserAttributeAccessWindowStart = "access-window-start"
+	UserAttributeAccessWindowEnd   = "access-window-end"
+	UserAttributeValidFrom         = "valid-from"
+	UserAttributeValidUntil        = "valid-until"
+	UserAttributeTimezone          = "timezone"
+)
+
+// Layouts of the restriction attributes. Guacamole writes times with seconds
+// but older records may omit them, so both are accepted on read.
+const (
+	accessWindowLayout      = "15:04:05"
+	accessWindowShortLayout = "15:04"
+	validityDateLayout      = "2006-01-02"
+)
+
+// AccessWindowStart returns the earliest time of day the user may log in.
+// Only the clock fields of t are meaningful. ok is false when the attribute
+// is unset or not a valid HH:mm[:ss] time.
+func (u *User) AccessWindowStart() (t time.Time, ok bool) {
+	return parseTimeOfDay(u.Attributes[UserAttributeAccessWindowStart])
+}
+
+// AccessWindowEnd returns the latest time of day the user may log in, with
+// the same conventions as AccessWindowStart.
+func (u *User) AccessWindowEnd() (t time.Time, ok bool) {
+	return parseTimeOfDay(u.Attributes[UserAttributeAccessWindowEnd])
+}
+
+// SetAccessWindow restricts login to the times of day between start and end;
+// their dates and locations are ignored. A zero start or end clears that
+// bound.
+func (u *User) SetAccessWindow(start, end time.Time) {
+	u.setAttribute(UserAttributeAccessWindowStart, formatIfSet(start, accessWindowLayout))
+	u.setAttribute(UserAttributeAccessWindowEnd, formatIfSet(end, accessWindowLayout))
+}
+
+// ValidFrom returns the first date on which the account is usable. ok is
+// false when the attribute is unset or not a valid yyyy-MM-dd date.
+func (u *User) ValidFrom() (t time.Time, ok bool) {
+	return parseDate(u.Attributes[UserAttributeValidFrom])
+}
+
+// ValidUntil returns the last date on which the account is usable, with the
+// same conventions as ValidFrom.
+func (u *User) ValidUntil() (t time.Time, ok bool) {
+	return parseDate(u.Attributes[UserAttributeValidUntil])
+}
+
+// SetValidityPeriod limits the account to the dates from through until; only
+// the calendar dates are stored. A zero from or until clears that bound.
+func (u *User) SetValidityPeriod(from, until time.Time) {
+	u.setAttribute(UserAttributeValidFrom, formatIfSet(from, validityDateLayout))
+	u.setAttribute(UserAttributeValidUntil, formatIfSet(until, validityDateLayout))
+}
+
+// Timezone returns the IANA zone the restrictions are evaluated in, or "" for
+// the server's default.
+func (u *User) Timezone() string {
+	return u.Attributes[UserAttributeTimezone]
+}
+
+// SetTimezone sets the IANA zone (e.g. "Europe/Berlin") the restrictions are
+// evaluated in. Passing an empty string clears it.
+func (u *User) SetTimezone(name string) {
+	u.setAttribute(UserAttributeTimezone, name)
+}
+
+// parseTimeOfDay parses an HH:mm:ss or HH:mm attribute value.
+func parseTimeOfDay(v string) (time.Time, bool) {
+	for _, layout := range []string{accessWindowLayout, accessWindowShortLayout} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDate parses a yyyy-MM-dd attribute value.
+func parseDate(v string) (time.Time, bool) {
+	t, err := time.Parse(validityDateLayout, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// formatIfSet formats t with layout, or returns "" for the zero time.
+func formatIfSet(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// Account lockout attribute keys. These are not part of the stock database
+// schema; they are maintained by extensions that track failed logins and
+// lock accounts, and are simply absent on servers without one.
+const (
+	UserAttributeLoginDisabled       = "guac-login-disabled"
+	UserAttributeFailedLoginAttempts = "guac-failed-login-attempts"
+	UserAttributeLockedUntil         = "guac-locked-until"
+)
+
+// LoginDisabled reports whether a lockout extension has disabled login for
+// the user. It is false when the attribute is absent. This is distinct from
+// the Disabled field, which an administrator sets.
+func (u *User) LoginDisabled() bool {
+	return u.Attributes[UserAttributeLoginDisabled] == "true"
+}
+
+// FailedLoginAttempts returns the number of consecutive failed logins
+// recorded for the user. ok is false when the attribute is absent or not a
+// non-negative integer.
+func (u *User) FailedLoginAttempts() (n int, ok bool) {
+	n, err := strconv.Atoi(u.Attributes[UserAttributeFailedLoginAttempts])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// LockedUntil returns the time at which the user's lockout expires. The
+// attribute may hold epoch milliseconds or an RFC 3339 timestamp. ok is false
+// when it is absent or in neither form.
+func (u *User) LockedUntil() (t time.Time, ok bool) {
+	v := u.Attributes[UserAttributeLockedUntil]
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return millisTime(ms)
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// IsLockedOut reports whether the user is locked out at now: login is
+// disabled by a lockout extension or the LockedUntil time is after now. Pass
+// time.Now() for the current state. Users without lockout attributes are
+// never locked out.
+func (u *User) IsLockedOut(now time.Time) bool {
+	if u.LoginDisabled() {
+		return true
+	}
+	until, ok := u.LockedUntil()
+	return ok && now.Before(until)
+}
+
+// LastActiveTime returns LastActive as a time.Time. ok is false when the user
+// has never logged in (LastActive is zero).
+func (u *User) LastActiveTime() (t time.Time, ok bool) {
+	return millisTime(u.LastActive)
+}
+
+// SeenWithin reports whether the user was last active within d before now,
+// e.g. SeenWithin(time.Now(), 30*24*time.Hour) for "active in the last 30
+// days". Users who have never logged in are never seen.
+func (u *User) SeenWithin(now time.Time, d time.Duration) bool {
+	t, ok := u.LastActiveTime()
+	return ok && now.Sub(t) <= d
+}
+
+// millisTime converts Guacamole's epoch-millisecond timestamps to time.Time,
+// reporting false for the zero value the API uses to mean "never".
+func millisTime(ms int64) (time.Time, bool) {
+	if ms == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// setAttribute stores value under key, allocating the attribute map if
+// necessary.
+func (u *User) setAttribute(key, value string) {
+	if u.Attributes == nil {
+		u.Attributes = NullableStringMap{}
+	}
+	u.Attributes[key] = value
+}
@@ -0,0 +1,301 @@
+package guacamole
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUser_SetEmail_valid(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"alice@example.com", "alice@example.com"},
+		{"Alice Smith <alice@example.com>", "alice@example.com"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			var u User
+			if err := u.SetEmail(tc.in); err != nil {
+				t.Fatalf("SetEmail(%q): %v", tc.in, err)
+			}
+			if got := u.Email(); got != tc.want {
+				t.Errorf("Email: got %q, want %q", got, tc.want)
+			}
+			if got := u.Attributes[UserAttributeEmailAddress]; got != tc.want {
+				t.Errorf("attribute: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUser_SetEmail_invalid_leaves_attributes_untouched(t *testing.T) {
+	for _, in := range []string{"alice", "alice@", "@example.com", "alice example.com"} {
+		t.Run(in, func(t *testing.T) {
+			u := User{Attributes: NullableStringMap{UserAttributeEmailAddress: "old@example.com"}}
+			if err := u.SetEmail(in); err == nil {
+				t.Fatalf("SetEmail(%q): expected error, got nil", in)
+			}
+			if got := u.Email(); got != "old@example.com" {
+				t.Errorf("Email: got %q, want unchanged %q", got, "old@example.com")
+			}
+		})
+	}
+}
+
+func TestUser_SetEmail_invalid_does_not_allocate(t *testing.T) {
+	var u User
+	if err := u.SetEmail("not an email"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if u.Attributes != nil {
+		t.Errorf("Attributes: got %v, want nil", u.Attributes)
+	}
+}
+
+func TestUser_LastActiveTime(t *testing.T) {
+	t.Run("never active", func(t *testing.T) {
+		var u User
+		if _, ok := u.LastActiveTime(); ok {
+			t.Error("LastActiveTime ok: got true, want false")
+		}
+		if u.SeenWithin(time.Now(), 365*24*time.Hour) {
+			t.Error("SeenWithin: got true for a never-active user")
+		}
+	})
+
+	t.Run("populated", func(t *testing.T) {
+		u := User{LastActive: 1700000000000}
+		got, ok := u.LastActiveTime()
+		if !ok {
+			t.Fatal("LastActiveTime ok: got false, want true")
+		}
+		if want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("LastActiveTime: got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("seen within", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		u := User{LastActive: now.Add(-time.Hour).UnixMilli()}
+		if !u.SeenWithin(now, 2*time.Hour) {
+			t.Error("SeenWithin(2h): got false, want true")
+		}
+		if u.SeenWithin(now, 30*time.Minute) {
+			t.Error("SeenWithin(30m): got true, want false")
+		}
+	})
+}
+
+func TestSelf_LastActiveTime(t *testing.T) {
+	var s Self
+	if _, ok := s.LastActiveTime(); ok {
+		t.Error("zero LastActive: got ok=true, want false")
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.LastActive = now.Add(-time.Minute).UnixMilli()
+	if _, ok := s.LastActiveTime(); !ok {
+		t.Error("populated LastActive: got ok=false, want true")
+	}
+	if !s.SeenWithin(now, time.Hour) {
+		t.Error("SeenWithin(1h): got false, want true")
+	}
+	if s.SeenWithin(now.Add(2*time.Hour), time.Hour) {
+		t.Error("SeenWithin(1h) two hours later: got true, want false")
+	}
+}
+
+func TestUser_SetLanguage_round_trip(t *testing.T) {
+	for _, code := range []string{"en", "en_US", "pt_BR", ""} {
+		t.Run(code, func(t *testing.T) {
+			var u User
+			if err := u.SetLanguage(code); err != nil {
+				t.Fatalf("SetLanguage(%q): %v", code, err)
+			}
+			data, err := json.Marshal(u)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var decoded User
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := decoded.Language(); got != code {
+				t.Errorf("Language: got %q, want %q", got, code)
+			}
+		})
+	}
+}
+
+func TestUser_SetLanguage_invalid(t *testing.T) {
+	for _, code := range []string{"EN", "en-US", "en_us", "english", "e"} {
+		t.Run(code, func(t *testing.T) {
+			u := User{Attributes: NullableStringMap{UserAttributeLanguage: "fr"}}
+			if err := u.SetLanguage(code); err == nil {
+				t.Fatalf("SetLanguage(%q): expected error, got nil", code)
+			}
+			if got := u.Language(); got != "fr" {
+				t.Errorf("Language: got %q, want unchanged %q", got, "fr")
+			}
+		})
+	}
+}
+
+func TestUser_AccessWindow_round_trip(t *testing.T) {
+	var u User
+	u.SetAccessWindow(
+		time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 17, 45, 15, 0, time.UTC),
+	)
+	if got := u.Attributes[UserAttributeAccessWindowStart]; got != "08:30:00" {
+		t.Errorf("start attribute: got %q, want %q", got, "08:30:00")
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded User
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	start, ok := decoded.AccessWindowStart()
+	if !ok || start.Hour() != 8 || start.Minute() != 30 {
+		t.Errorf("AccessWindowStart: got %v, %v; want 08:30", start, ok)
+	}
+	end, ok := decoded.AccessWindowEnd()
+	if !ok || end.Hour() != 17 || end.Minute() != 45 || end.Second() != 15 {
+		t.Errorf("AccessWindowEnd: got %v, %v; want 17:45:15", end, ok)
+	}
+}
+
+func TestUser_ValidityPeriod_round_trip(t *testing.T) {
+	var u User
+	u.SetValidityPeriod(time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), time.Time{})
+	u.SetTimezone("Europe/Berlin")
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded User
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	from, ok := decoded.ValidFrom()
+	if !ok || from.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("ValidFrom: got %v, %v; want 2024-03-01", from, ok)
+	}
+	if _, ok := decoded.ValidUntil(); ok {
+		t.Error("ValidUntil: ok for cleared bound")
+	}
+	if got := decoded.Timezone(); got != "Europe/Berlin" {
+		t.Errorf("Timezone: got %q, want %q", got, "Europe/Berlin")
+	}
+}
+
+func TestUser_restriction_attributes_invalid(t *testing.T) {
+	u := User{Attributes: NullableStringMap{
+		UserAttributeAccessWindowStart: "8am",
+		UserAttributeAccessWindowEnd:   "25:00",
+		UserAttributeValidFrom:         "01/03/2024",
+	}}
+	if _, ok := u.AccessWindowStart(); ok {
+		t.Error("AccessWindowStart: ok for invalid value")
+	}
+	if _, ok := u.AccessWindowEnd(); ok {
+		t.Error("AccessWindowEnd: ok for invalid value")
+	}
+	if _, ok := u.ValidFrom(); ok {
+		t.Error("ValidFrom: ok for invalid value")
+	}
+	if _, ok := u.ValidUntil(); ok {
+		t.Error("ValidUntil: ok for unset value")
+	}
+}
+
+func TestUser_AccessWindow_accepts_short_layout(t *testing.T) {
+	u := User{Attributes: NullableStringMap{UserAttributeAccessWindowStart: "09:15"}}
+	start, ok := u.AccessWindowStart()
+	if !ok || start.Hour() != 9 || start.Minute() != 15 {
+		t.Errorf("AccessWindowStart: got %v, %v; want 09:15", start, ok)
+	}
+}
+
+func TestUser_lockout_attributes_present(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour)
+	cases := map[string]string{
+		"epoch millis": strconv.FormatInt(until.UnixMilli(), 10),
+		"RFC 3339":     until.Format(time.RFC3339Nano),
+	}
+	for name, lockedUntil := range cases {
+		t.Run(name, func(t *testing.T) {
+			u := User{Attributes: NullableStringMap{
+				UserAttributeFailedLoginAttempts: "5",
+				UserAttributeLockedUntil:         lockedUntil,
+			}}
+			if n, ok := u.FailedLoginAttempts(); !ok || n != 5 {
+				t.Errorf("FailedLoginAttempts: got %d, %v; want 5, true", n, ok)
+			}
+			if got, ok := u.LockedUntil(); !ok || !got.Equal(until) {
+				t.Errorf("LockedUntil: got %v, %v; want %v, true", got, ok, until)
+			}
+			if u.LoginDisabled() {
+				t.Error("LoginDisabled: got true, want false")
+			}
+			if !u.IsLockedOut(now) {
+				t.Error("IsLockedOut: got false for future lock")
+			}
+			if u.IsLockedOut(until) {
+				t.Error("IsLockedOut: got true once the lock has expired")
+			}
+		})
+	}
+
+	expired := User{Attributes: NullableStringMap{UserAttributeLockedUntil: "1000"}}
+	if expired.IsLockedOut(now) {
+		t.Error("IsLockedOut: got true for expired lock")
+	}
+	disabled := User{Attributes: NullableStringMap{UserAttributeLoginDisabled: "true"}}
+	if !disabled.LoginDisabled() || !disabled.IsLockedOut(now) {
+		t.Error("login-disabled user not reported as locked out")
+	}
+}
+
+func TestUser_lockout_attributes_absent(t *testing.T) {
+	for _, u := range []User{
+		{},
+		{Attributes: NullableStringMap{
+			UserAttributeFailedLoginAttempts: "many",
+			UserAttributeLockedUntil:         "tomorrow",
+		}},
+	} {
+		if _, ok := u.FailedLoginAttempts(); ok {
+			t.Errorf("FailedLoginAttempts: ok for %v", u.Attributes)
+		}
+		if _, ok := u.LockedUntil(); ok {
+			t.Errorf("LockedUntil: ok for %v", u.Attributes)
+		}
+		if u.LoginDisabled() || u.IsLockedOut(time.Now()) {
+			t.Errorf("user with attributes %v reported as locked out", u.Attributes)
+		}
+	}
+}
+
+func TestUser_PasswordExpired(t *testing.T) {
+	var u User
+	if u.PasswordExpired() {
+		t.Error("PasswordExpired: got true for user without attributes")
+	}
+	u.SetPasswordExpired(true)
+	if !u.PasswordExpired() || u.Attributes[UserAttributeExpired] != "true" {
+		t.Errorf("after SetPasswordExpired(true): attributes %v", u.Attributes)
+	}
+	u.SetPasswordExpired(false)
+	if u.PasswordExpired() || u.Attributes[UserAttributeExpired] != "" {
+		t.Errorf("after SetPasswordExpired(false): attributes %v", u.Attributes)
+	}
+}
@@ -12,6 +12,10 @@ func (c *Client) ListUserGroups(ctx context.Context) (map[string]UserGroup, erro
 	if err := c.get(ctx, c.dataPath("userGroups"), &result); err != nil {
 		return nil, fmt.Errorf("guacamole: list user groups: %w", err)
 	}
+	for id, g := range result {
+		g.reconcileDisabled()
+		result[id] = g
+	}
 	return result, nil
 }
 
@@ -24,12 +28,40 @@ func (c *Client) CreateUserGroup(ctx context.Context, group UserGroup) (*UserGro
 	return &result, nil
 }
 
+// CreateUserGroupIfNotExists creates group unless a user group with the same
+// identifier already exists. It returns the created or existing group and
+// reports whether a new group was created. A concurrent creation between the
+// existence check and the create call is also reported as created == false.
+func (c *Client) CreateUserGroupIfNotExists(ctx context.Context, group UserGroup) (*UserGroup, bool, error) {
+	existing, err := c.GetUserGroup(ctx, group.Identifier)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !IsNotFound(err) {
+		return nil, false, err
+	}
+
+	created, err := c.CreateUserGroup(ctx, group)
+	if err == nil {
+		return created, true, nil
+	}
+	if !IsAlreadyExists(err) {
+		return nil, false, err
+	}
+	existing, err = c.GetUserGroup(ctx, group.Identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
 // GetUserGroup retrieves the user group with the given identifier.
 func (c *Client) GetUserGroup(ctx context.Context, id string) (*UserGroup, error) {
 	var result UserGroup
 	if err := c.get(ctx, c.dataPath("userGroups", id), &result); err != nil {
 		return nil, fmt.Errorf("guacamole: get user group %s: %w", id, err)
 	}
+	result.reconcileDisabled()
 	return &result, nil
 }
 
@@ -42,6 +74,43 @@ func (c *Client) UpdateUserGroup(ctx context.Context, id string, group UserGroup
 	return nil
 }
 
+// DisableUserGroup disables the user group identified by id, so that its
+// members no longer receive the permissions granted through it. The group's
+// other attributes are preserved.
+func (c *Client) DisableUserGroup(ctx context.Context, id string) error {
+	if err := c.setUserGroupDisabled(ctx, id, true); err != nil {
+		return fmt.Errorf("guacamole: disable user group %s: %w", id, err)
+	}
+	return nil
+}
+
+// EnableUserGroup re-enables the user group identified by id, preserving its
+// other attributes.
+func (c *Client) EnableUserGroup(ctx context.Context, id string) error {
+	if err := c.setUserGroupDisabled(ctx, id, false); err != nil {
+		return fmt.Errorf("guacamole: enable user group %s: %w", id, err)
+	}
+	return nil
+}
+
+// setUserGroupDisabled fetches the group identified by id and PUTs it back
+// with both the Disabled field and the disabled attribute set to disabled.
+func (c *Client) setUserGroupDisabled(ctx context.Context, id string, disabled bool) error {
+	group, err := c.GetUserGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if group.Attributes == nil {
+		group.Attributes = NullableStringMap{}
+	}
+	group.Disabled = disabled
+	group.Attributes[UserGroupAttributeDisabled] = ""
+	if disabled {
+		group.Attributes[UserGroupAttributeDisabled] = "true"
+	}
+	return c.UpdateUserGroup(ctx, id, *group)
+}
+
 // DeleteUserGroup permanently removes the user group with the given
 // identifier.
 func (c *Client) DeleteUserGroup(ctx context.Context, id string) error {
@@ -44,6 +44,73 @@ func TestCreateUserGroup(t *testing.T) {
 	}
 }
 
+func TestCreateUserGroup_already_exists(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(t, w, http.StatusBadRequest, ErrTypeBadRequest, `Group "admins" already exists.`)
+	})
+	_, err := c.CreateUserGroup(context.Background(), UserGroup{Identifier: "admins"})
+	if !IsAlreadyExists(err) {
+		t.Errorf("IsAlreadyExists: got false, want true (err=%v)", err)
+	}
+}
+
+func TestCreateUserGroupIfNotExists_creates(t *testing.T) {
+	var posted bool
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assertPath(t, r, "/api/session/data/postgresql/userGroups/admins")
+			writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, `No such user group: "admins"`)
+		case http.MethodPost:
+			assertPath(t, r, "/api/session/data/postgresql/userGroups")
+			posted = true
+			writeJSON(t, w, UserGroup{Identifier: "admins"})
+		}
+	})
+	ug, created, err := c.CreateUserGroupIfNotExists(context.Background(), UserGroup{Identifier: "admins"})
+	if err != nil {
+		t.Fatalf("CreateUserGroupIfNotExists: %v", err)
+	}
+	if !created || !posted {
+		t.Errorf("created: got %v (posted=%v), want true", created, posted)
+	}
+	if ug.Identifier != "admins" {
+		t.Errorf("Identifier: got %q, want %q", ug.Identifier, "admins")
+	}
+}
+
+func TestCreateUserGroupIfNotExists_existing(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s request; group already exists", r.Method)
+		}
+		writeJSON(t, w, UserGroup{Identifier: "admins", Attributes: NullableStringMap{"disabled": ""}})
+	})
+	ug, created, err := c.CreateUserGroupIfNotExists(context.Background(), UserGroup{Identifier: "admins"})
+	if err != nil {
+		t.Fatalf("CreateUserGroupIfNotExists: %v", err)
+	}
+	if created {
+		t.Error("created: got true, want false")
+	}
+	if ug.Identifier != "admins" {
+		t.Errorf("Identifier: got %q, want %q", ug.Identifier, "admins")
+	}
+}
+
+func TestCreateUserGroupIfNotExists_error(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission Denied.")
+	})
+	_, created, err := c.CreateUserGroupIfNotExists(context.Background(), UserGroup{Identifier: "admins"})
+	if !IsPermissionDenied(err) {
+		t.Errorf("IsPermissionDenied: got false, want true (err=%v)", err)
+	}
+	if created {
+		t.Error("created: got true, want false")
+	}
+}
+
 func TestGetUserGroup(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodGet)
@@ -215,3 +282,95 @@ func TestUpdateUserGroupParentGroups(t *testing.T) {
 		t.Fatalf("UpdateUserGroupParentGroups: %v", err)
 	}
 }
+
+func TestGetUserGroup_reconciles_disabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		disabled bool
+		attr     string
+		hasAttr  bool
+	}{
+		{"field only", `{"identifier":"g","disabled":true,"attributes":{}}`, true, "", false},
+		{"attribute only", `{"identifier":"g","attributes":{"disabled":"true"}}`, true, "true", true},
+		{"field wins over empty attribute", `{"identifier":"g","disabled":true,"attributes":{"disabled":""}}`, true, "true", true},
+		{"null attribute", `{"identifier":"g","attributes":{"disabled":null}}`, false, "", false},
+		{"enabled", `{"identifier":"g","disabled":false,"attributes":{"disabled":""}}`, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			})
+			g, err := c.GetUserGroup(context.Background(), "g")
+			if err != nil {
+				t.Fatalf("GetUserGroup: %v", err)
+			}
+			if g.Disabled != tt.disabled {
+				t.Errorf("Disabled: got %v, want %v", g.Disabled, tt.disabled)
+			}
+			attr, ok := g.Attributes[UserGroupAttributeDisabled]
+			if ok != tt.hasAttr || attr != tt.attr {
+				t.Errorf("disabled attribute: got %q (present=%v), want %q (present=%v)", attr, ok, tt.attr, tt.hasAttr)
+			}
+		})
+	}
+}
+
+func TestListUserGroups_reconciles_disabled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"a":{"identifier":"a","attributes":{"disabled":"true"}},"b":{"identifier":"b","attributes":{}}}`))
+	})
+	groups, err := c.ListUserGroups(context.Background())
+	if err != nil {
+		t.Fatalf("ListUserGroups: %v", err)
+	}
+	if !groups["a"].Disabled || groups["b"].Disabled {
+		t.Errorf("Disabled: got a=%v b=%v, want a=true b=false", groups["a"].Disabled, groups["b"].Disabled)
+	}
+}
+
+func TestDisableEnableUserGroup_round_trip(t *testing.T) {
+	stored := UserGroup{Identifier: "ops", Attributes: NullableStringMap{"disabled": "", "note": "on call"}}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/userGroups/ops")
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(t, w, stored)
+		case http.MethodPut:
+			var put UserGroup
+			mustReadJSON(t, r, &put)
+			stored = put
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	})
+	ctx := context.Background()
+
+	if err := c.DisableUserGroup(ctx, "ops"); err != nil {
+		t.Fatalf("DisableUserGroup: %v", err)
+	}
+	if !stored.Disabled || stored.Attributes["disabled"] != "true" {
+		t.Errorf("after disable: got Disabled=%v attribute=%q, want true and %q", stored.Disabled, stored.Attributes["disabled"], "true")
+	}
+	if stored.Attributes["note"] != "on call" {
+		t.Errorf("note attribute: got %q, want preserved", stored.Attributes["note"])
+	}
+	g, err := c.GetUserGroup(ctx, "ops")
+	if err != nil || !g.Disabled {
+		t.Fatalf("GetUserGroup after disable: got %+v, %v; want disabled", g, err)
+	}
+
+	if err := c.EnableUserGroup(ctx, "ops"); err != nil {
+		t.Fatalf("EnableUserGroup: %v", err)
+	}
+	if stored.Disabled || stored.Attributes["disabled"] != "" {
+		t.Errorf("after enable: got Disabled=%v attribute=%q, want false and empty", stored.Disabled, stored.Attributes["disabled"])
+	}
+	if stored.Attributes["note"] != "on call" {
+		t.Errorf("note attribute: got %q, want preserved", stored.Attributes["note"])
+	}
+}
@@ -3,6 +3,9 @@ package guacamole
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // System permission constants.
@@ -34,6 +37,107 @@ func (c *Client) ListUsers(ctx context.Context) (map[string]User, error) {
 	return result, nil
 }
 
+// UsersIterator yields users one at a time in username order. Create one with
+// IterateUsers. Guacamole has no server-side paging for users, so the full
+// list is fetched once up front; the iterator releases each user as it is
+// consumed so that callers processing large directories can stop early and
+// keep only their own working set alive.
+type UsersIterator struct {
+	users []User
+	pos   int
+	cur   User
+}
+
+// IterateUsers fetches all users visible to the authenticated user and
+// returns an iterator over them sorted by username.
+//
+//	it, err := client.IterateUsers(ctx)
+//	for it.Next() {
+//	    u := it.User()
+//	    ...
+//	}
+func (c *Client) IterateUsers(ctx context.Context) (*UsersIterator, error) {
+	users, err := c.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]User, 0, len(users))
+	for name, u := range users {
+		if u.Username == "" {
+			u.Username = name
+		}
+		sorted = append(sorted, u)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Username < sorted[j].Username })
+	return &UsersIterator{users: sorted}, nil
+}
+
+// Next advances the iterator and reports whether a user is available via
+// User.
+func (it *UsersIterator) Next() bool {
+	if it.pos >= len(it.users) {
+		it.cur = User{}
+		return false
+	}
+	it.cur = it.users[it.pos]
+	it.users[it.pos] = User{}
+	it.pos++
+	return true
+}
+
+// User returns the user at the current position. It is only valid after a
+// call to Next that returned true.
+func (it *UsersIterator) User() User {
+	return it.cur
+}
+
+// Remaining returns the number of users not yet yielded.
+func (it *UsersIterator) Remaining() int {
+	return len(it.users) - it.pos
+}
+
+// userSearchAttributes are the attributes SearchUsers matches against in
+// addition to the username.
+var userSearchAttributes = []string{
+	UserAttributeFullName,
+	UserAttributeEmailAddress,
+	UserAttributeOrganizationRole,
+}
+
+// SearchUsers returns the users whose username, full name, email address or
+// organizational role contains query, ignoring case, sorted by username.
+// Guacamole has no server-side search, so all users are listed and filtered
+// locally. An empty query matches every user.
+func (c *Client) SearchUsers(ctx context.Context, query string) ([]User, error) {
+	it, err := c.IterateUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := strings.ToLower(query)
+	matches := []User{}
+	for it.Next() {
+		u := it.User()
+		if userMatches(&u, q) {
+			matches = append(matches, u)
+		}
+	}
+	return matches, nil
+}
+
+// userMatches reports whether the lowercase query q is a substring of u's
+// username or any of its searchable attributes.
+func userMatches(u *User, q string) bool {
+	if strings.Contains(strings.ToLower(u.Username), q) {
+		return true
+	}
+	for _, key := range userSearchAttributes {
+		if strings.Contains(strings.ToLower(u.Attributes[key]), q) {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateUser creates a new user and returns the created resource. The Password
 // field of the returned User will be empty (the API does not echo passwords).
 func (c *Client) CreateUser(ctx context.Context, user User) (*User, error) {
@@ -44,6 +148,21 @@ func (c *Client) CreateUser(ctx context.Context, user User) (*User, error) {
 	return &result, nil
 }
 
+// CreateDisabledUser creates user in the disabled state, to be enabled later.
+// Both the Disabled field and the disabled attribute are set so the account
+// is created disabled whichever one the backend reads; the other attributes
+// of user are sent unchanged and the caller's map is not modified.
+func (c *Client) CreateDisabledUser(ctx context.Context, user User) (*User, error) {
+	attrs := make(NullableStringMap, len(user.Attributes)+1)
+	for k, v := range user.Attributes {
+		attrs[k] = v
+	}
+	attrs[UserAttributeDisabled] = "true"
+	user.Attributes = attrs
+	user.Disabled = true
+	return c.CreateUser(ctx, user)
+}
+
 // GetUser retrieves the user with the given username.
 func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
 	var result User
@@ -53,9 +172,57 @@ func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
 	return &result, nil
 }
 
+// UserDetail aggregates a user with their effective permissions and direct
+// group memberships, as returned by GetUserDetail.
+type UserDetail struct {
+	User                 User
+	EffectivePermissions Permissions
+	Groups               []string
+}
+
+// GetUserDetail concurrently fetches the user, their effective permissions,
+// and their direct group memberships, saving two round-trips compared to
+// calling GetUser, GetUserEffectivePermissions, and GetUserGroups in turn. If
+// any sub-call fails, the returned error names it (e.g. "groups").
+func (c *Client) GetUserDetail(ctx context.Context, username string) (*UserDetail, error) {
+	var (
+		wg                          sync.WaitGroup
+		user                        *User
+		perms                       *Permissions
+		groups                      []string
+		userErr, permsErr, groupErr error
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		user, userErr = c.GetUser(ctx, username)
+	}()
+	go func() {
+		defer wg.Done()
+		perms, permsErr = c.GetUserEffectivePermissions(ctx, username)
+	}()
+	go func() {
+		defer wg.Done()
+		groups, groupErr = c.GetUserGroups(ctx, username)
+	}()
+	wg.Wait()
+
+	switch {
+	case userErr != nil:
+		return nil, fmt.Errorf("guacamole: get user detail %s: user: %w", username, userErr)
+	case permsErr != nil:
+		return nil, fmt.Errorf("guacamole: get user detail %s: effective permissions: %w", username, permsErr)
+	case groupErr != nil:
+		return nil, fmt.Errorf("guacamole: get user detail %s: groups: %w", username, groupErr)
+	}
+	return &UserDetail{User: *user, EffectivePermissions: *perms, Groups: groups}, nil
+}
+
 // UpdateUser replaces the user identified by username with the supplied User.
 // To change a user's password, include the new password in the Password field.
-// To leave the password unchanged, omit it (empty string).
+// To leave the password unchanged, omit it (empty string). Because an empty
+// Password always means "keep", use UpdateUserWithPassword to set an empty
+// password.
 func (c *Client) UpdateUser(ctx context.Context, username string, user User) error {
 	if err := c.put(ctx, c.dataPath("users", username), user); err != nil {
 		return fmt.Errorf("guacamole: update user %s: %w", username, err)
@@ -63,6 +230,24 @@ func (c *Client) UpdateUser(ctx context.Context, username string, user User) err
 	return nil
 }
 
+// userWithPassword serialises a User with its password always present, even
+// when empty; the outer Password field shadows User.Password.
+type userWithPassword struct {
+	User
+	Password string `json:"password"`
+}
+
+// UpdateUserWithPassword is UpdateUser with the password stated explicitly:
+// newPassword is always sent and user.Password is ignored. An empty
+// newPassword sets an empty password rather than keeping the current one,
+// which is occasionally wanted in test setups.
+func (c *Client) UpdateUserWithPassword(ctx context.Context, username string, user User, newPassword string) error {
+	if err := c.put(ctx, c.dataPath("users", username), userWithPassword{User: user, Password: newPassword}); err != nil {
+		return fmt.Errorf("guacamole: update user %s: %w", username, err)
+	}
+	return nil
+}
+
 // DeleteUser permanently removes the user with the given username.
 func (c *Client) DeleteUser(ctx context.Context, username string) error {
 	if err := c.delete(ctx, c.dataPath("users", username)); err != nil {
@@ -71,6 +256,76 @@ func (c *Client) DeleteUser(ctx context.Context, username string) error {
 	return nil
 }
 
+// BulkDeleteUsers deletes every user in usernames concurrently, with a
+// bounded number of requests in flight. The result has one entry per distinct
+// username: nil when the user was deleted or did not exist, otherwise the
+// error returned for that user.
+func (c *Client) BulkDeleteUsers(ctx context.Context, usernames []string) map[string]error {
+	var mu sync.Mutex
+	results := make(map[string]error, len(usernames))
+	forEachBounded(dedupe(usernames), bulkWorkers, func(username string) {
+		err := c.DeleteUser(ctx, username)
+		if IsNotFound(err) {
+			err = nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[username] = err
+	})
+	return results
+}
+
+// BulkUpdateUserAttributes merges attrs into the attributes of every user in
+// usernames concurrently, with a bounded number of requests in flight. Each
+// user is read, the given keys are overwritten while all other attributes are
+// kept, and the user is written back without a password so the existing one
+// is unchanged. The result has one entry per distinct username: nil on
+// success, otherwise the error returned for that user.
+func (c *Client) BulkUpdateUserAttributes(ctx context.Context, usernames []string, attrs NullableStringMap) map[string]error {
+	var mu sync.Mutex
+	results := make(map[string]error, len(usernames))
+	forEachBounded(dedupe(usernames), bulkWorkers, func(username string) {
+		err := c.mergeUserAttributes(ctx, username, attrs)
+		mu.Lock()
+		defer mu.Unlock()
+		results[username] = err
+	})
+	return results
+}
+
+// mergeUserAttributes performs the read-merge-write of one user for
+// BulkUpdateUserAttributes.
+func (c *Client) mergeUserAttributes(ctx context.Context, username string, attrs NullableStringMap) error {
+	user, err := c.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	for k, v := range attrs {
+		user.setAttribute(k, v)
+	}
+	user.Password = ""
+	return c.UpdateUser(ctx, username, *user)
+}
+
+// RequirePasswordChange marks the user's password as expired so that they
+// must choose a new one at their next login. Other attributes and the
+// current password are left unchanged.
+func (c *Client) RequirePasswordChange(ctx context.Context, username string) error {
+	if err := c.mergeUserAttributes(ctx, username, NullableStringMap{UserAttributeExpired: "true"}); err != nil {
+		return fmt.Errorf("guacamole: require password change for %s: %w", username, err)
+	}
+	return nil
+}
+
+// ClearPasswordChangeRequirement is the inverse of RequirePasswordChange: it
+// clears the user's expired attribute, leaving everything else unchanged.
+func (c *Client) ClearPasswordChangeRequirement(ctx context.Context, username string) error {
+	if err := c.mergeUserAttributes(ctx, username, NullableStringMap{UserAttributeExpired: ""}); err != nil {
+		return fmt.Errorf("guacamole: clear password change requirement for %s: %w", username, err)
+	}
+	return nil
+}
+
 // ── Permissions ───────────────────────────────────────────────────────────────
 
 // GetUserPermissions returns the explicit permissions granted directly to the
@@ -116,6 +371,39 @@ func (c *Client) GetUserGroups(ctx context.Context, username string) ([]string,
 	return result, nil
 }
 
+// GetUserEffectiveGroups returns the sorted identifiers of every user group
+// the user belongs to, directly or through nested group membership. Parent
+// groups are walked breadth-first and each group is visited once, so cycles
+// in the membership graph are tolerated.
+func (c *Client) GetUserEffectiveGroups(ctx context.Context, username string) ([]string, error) {
+	direct, err := c.GetUserGroups(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	visited := map[string]bool{}
+	queue := direct
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+		if visited[group] {
+			continue
+		}
+		visited[group] = true
+
+		parents, err := c.GetUserGroupParentGroups(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("guacamole: get effective groups for %s: %w", username, err)
+		}
+		queue = append(queue, parents...)
+	}
+	result := make([]string, 0, len(visited))
+	for group := range visited {
+		result = append(result, group)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
 // UpdateUserGroups applies the given JSON Patch operations to the user's group
 // membership list.
 func (c *Client) UpdateUserGroups(ctx context.Context, username string, ops []PatchOperation) error {
@@ -125,6 +413,59 @@ func (c *Client) UpdateUserGroups(ctx context.Context, username string, ops []Pa
 	return nil
 }
 
+// SetUserGroups declaratively sets the user's direct group memberships to
+// exactly desiredGroups. It reads the current memberships, then adds and
+// removes only what differs in a single PATCH. Duplicates and ordering in
+// desiredGroups are ignored, and no request is sent when nothing changes.
+func (c *Client) SetUserGroups(ctx context.Context, username string, desiredGroups []string) error {
+	current, err := c.GetUserGroups(ctx, username)
+	if err != nil {
+		return err
+	}
+	ops := membershipOps(current, desiredGroups)
+	if len(ops) == 0 {
+		return nil
+	}
+	return c.UpdateUserGroups(ctx, username, ops)
+}
+
+// membershipOps returns the add/remove operations that turn the current
+// membership list into desired. Removals come first, and each half is sorted
+// so the PATCH body is deterministic.
+func membershipOps(current, desired []string) []PatchOperation {
+	have := make(map[string]bool, len(current))
+	for _, id := range current {
+		have[id] = true
+	}
+	want := make(map[string]bool, len(desired))
+	for _, id := range desired {
+		want[id] = true
+	}
+
+	var removes, adds []string
+	for id := range have {
+		if !want[id] {
+			removes = append(removes, id)
+		}
+	}
+	for id := range want {
+		if !have[id] {
+			adds = append(adds, id)
+		}
+	}
+	sort.Strings(removes)
+	sort.Strings(adds)
+
+	ops := make([]PatchOperation, 0, len(removes)+len(adds))
+	for _, id := range removes {
+		ops = append(ops, RemoveGroupMembership(id))
+	}
+	for _, id := range adds {
+		ops = append(ops, AddGroupMembership(id))
+	}
+	return ops
+}
+
 // ── Patch helpers ─────────────────────────────────────────────────────────────
 
 // AddConnectionPermission returns a PatchOperation that grants the given
@@ -210,3 +551,18 @@ func AddGroupMembership(identifier string) PatchOperation {
 func RemoveGroupMembership(identifier string) PatchOperation {
 	return PatchOperation{Op: "remove", Path: "/", Value: identifier}
 }
+
+// ReplaceOp returns an RFC 6902 "replace" PatchOperation. Stock Guacamole
+// permission and membership endpoints accept only "add" and "remove"; use
+// ReplaceOp only with extension endpoints that implement full JSON Patch
+// semantics.
+func ReplaceOp(path, value string) PatchOperation {
+	return PatchOperation{Op: "replace", Path: path, Value: value}
+}
+
+// TestOp returns an RFC 6902 "test" PatchOperation, which makes the whole
+// patch fail unless the value at path equals value. As with ReplaceOp, only
+// extension endpoints implementing full JSON Patch semantics support it.
+func TestOp(path, value string) PatchOperation {
+	return PatchOperation{Op: "test", Path: path, Value: value}
+}
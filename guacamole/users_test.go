@@ -3,7 +3,11 @@ package guacamole
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -25,6 +29,51 @@ func TestListUsers(t *testing.T) {
 	}
 }
 
+func TestIterateUsers_full_enumeration(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/users")
+		writeJSON(t, w, map[string]User{
+			"carol": {Username: "carol"},
+			"alice": {Username: "alice"},
+			"bob":   {},
+		})
+	})
+	it, err := c.IterateUsers(context.Background())
+	if err != nil {
+		t.Fatalf("IterateUsers: %v", err)
+	}
+	var got []string
+	for it.Next() {
+		got = append(got, it.User().Username)
+	}
+	if strings.Join(got, ",") != "alice,bob,carol" {
+		t.Errorf("users: got %v, want [alice bob carol]", got)
+	}
+	if it.Remaining() != 0 {
+		t.Errorf("Remaining: got %d, want 0", it.Remaining())
+	}
+}
+
+func TestIterateUsers_early_termination(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]User{
+			"alice": {Username: "alice"},
+			"bob":   {Username: "bob"},
+			"carol": {Username: "carol"},
+		})
+	})
+	it, err := c.IterateUsers(context.Background())
+	if err != nil {
+		t.Fatalf("IterateUsers: %v", err)
+	}
+	if !it.Next() || it.User().Username != "alice" {
+		t.Fatalf("first user: got %q, want alice", it.User().Username)
+	}
+	if it.Remaining() != 2 {
+		t.Errorf("Remaining: got %d, want 2", it.Remaining())
+	}
+}
+
 func TestCreateUser(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPost)
@@ -89,6 +138,55 @@ func TestGetUser_null_attributes_normalised(t *testing.T) {
 	}
 }
 
+func TestGetUserDetail(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodGet)
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/users/alice":
+			writeJSON(t, w, User{Username: "alice", Attributes: NullableStringMap{"guac-full-name": "Alice"}})
+		case "/api/session/data/postgresql/users/alice/effectivePermissions":
+			writeJSON(t, w, Permissions{SystemPermissions: []string{SystemPermissionCreateUser}})
+		case "/api/session/data/postgresql/users/alice/userGroups":
+			writeJSON(t, w, []string{"admins"})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	d, err := c.GetUserDetail(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("GetUserDetail: %v", err)
+	}
+	if d.User.Attributes["guac-full-name"] != "Alice" {
+		t.Errorf("User: got %+v", d.User)
+	}
+	if len(d.EffectivePermissions.SystemPermissions) != 1 {
+		t.Errorf("SystemPermissions: got %v", d.EffectivePermissions.SystemPermissions)
+	}
+	if len(d.Groups) != 1 || d.Groups[0] != "admins" {
+		t.Errorf("Groups: got %v, want [admins]", d.Groups)
+	}
+}
+
+func TestGetUserDetail_partial_failure_names_sub_call(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/users/alice/userGroups":
+			writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission Denied.")
+		case "/api/session/data/postgresql/users/alice":
+			writeJSON(t, w, User{Username: "alice"})
+		default:
+			writeJSON(t, w, Permissions{})
+		}
+	})
+	_, err := c.GetUserDetail(context.Background(), "alice")
+	if !IsPermissionDenied(err) {
+		t.Fatalf("IsPermissionDenied: got false, want true (err=%v)", err)
+	}
+	if !strings.Contains(err.Error(), "groups") {
+		t.Errorf("error %q does not name the failed sub-call", err)
+	}
+}
+
 func TestUpdateUser(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPut)
@@ -101,6 +199,52 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestUpdateUser_password_semantics(t *testing.T) {
+	cases := []struct {
+		name      string
+		update    func(c *Client) error
+		wantField bool
+		want      string
+	}{
+		{"keep", func(c *Client) error {
+			return c.UpdateUser(context.Background(), "alice", User{Username: "alice"})
+		}, false, ""},
+		{"change", func(c *Client) error {
+			return c.UpdateUserWithPassword(context.Background(), "alice", User{Username: "alice", Password: "ignored"}, "s3cret")
+		}, true, "s3cret"},
+		{"explicit clear", func(c *Client) error {
+			return c.UpdateUserWithPassword(context.Background(), "alice", User{Username: "alice"}, "")
+		}, true, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertMethod(t, r, http.MethodPut)
+				assertPath(t, r, "/api/session/data/postgresql/users/alice")
+				var body map[string]json.RawMessage
+				mustReadJSON(t, r, &body)
+				raw, ok := body["password"]
+				if ok != tc.wantField {
+					t.Fatalf("password present: got %v, want %v (body %v)", ok, tc.wantField, body)
+				}
+				if ok {
+					var got string
+					if err := json.Unmarshal(raw, &got); err != nil || got != tc.want {
+						t.Errorf("password: got %s, want %q", raw, tc.want)
+					}
+				}
+				if string(body["username"]) != `"alice"` {
+					t.Errorf("username: got %s", body["username"])
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+			if err := tc.update(c); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodDelete)
@@ -112,6 +256,176 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
+func TestSearchUsers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertPath(t, r, "/api/session/data/postgresql/users")
+		writeJSON(t, w, map[string]User{
+			"jdoe":  {Username: "jdoe", Attributes: NullableStringMap{UserAttributeFullName: "Jane Smith"}},
+			"bob":   {Username: "bob", Attributes: NullableStringMap{UserAttributeEmailAddress: "bob@SMITH.example"}},
+			"carol": {Username: "carol", Attributes: NullableStringMap{UserAttributeFullName: "Carol Jones"}},
+		})
+	})
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"smith", []string{"bob", "jdoe"}},
+		{"jane", []string{"jdoe"}},
+		{"@smith.example", []string{"bob"}},
+		{"CAR", []string{"carol"}},
+		{"nobody", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			got, err := c.SearchUsers(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("SearchUsers: %v", err)
+			}
+			var names []string
+			for _, u := range got {
+				names = append(names, u.Username)
+			}
+			if strings.Join(names, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("SearchUsers(%q): got %v, want %v", tc.query, names, tc.want)
+			}
+		})
+	}
+}
+
+func TestBulkDeleteUsers(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodDelete)
+		switch r.URL.Path {
+		case "/api/session/data/postgresql/users/alice":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/session/data/postgresql/users/gone":
+			writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "No such user")
+		case "/api/session/data/postgresql/users/root":
+			writeAPIError(t, w, http.StatusForbidden, ErrTypePermissionDenied, "Permission denied")
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	got := c.BulkDeleteUsers(context.Background(), []string{"alice", "gone", "root", "alice"})
+	if len(got) != 3 {
+		t.Fatalf("results: got %d entries, want 3: %v", len(got), got)
+	}
+	if err := got["alice"]; err != nil {
+		t.Errorf("alice: got %v, want nil", err)
+	}
+	if err, ok := got["gone"]; !ok || err != nil {
+		t.Errorf("gone: got %v (present=%v), want nil entry", err, ok)
+	}
+	if err := got["root"]; !IsPermissionDenied(err) {
+		t.Errorf("root: got %v, want permission denied", err)
+	}
+}
+
+func TestBulkUpdateUserAttributes(t *testing.T) {
+	var mu sync.Mutex
+	written := map[string]map[string]json.RawMessage{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		username := strings.TrimPrefix(r.URL.Path, "/api/session/data/postgresql/users/")
+		switch r.Method {
+		case http.MethodGet:
+			if username == "ghost" {
+				writeAPIError(t, w, http.StatusNotFound, ErrTypeNotFound, "No such user")
+				return
+			}
+			writeJSON(t, w, User{Username: username, Attributes: NullableStringMap{
+				UserAttributeFullName: "Name of " + username,
+				UserAttributeTimezone: "UTC",
+			}})
+		case http.MethodPut:
+			var body map[string]json.RawMessage
+			mustReadJSON(t, r, &body)
+			mu.Lock()
+			written[username] = body
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	got := c.BulkUpdateUserAttributes(context.Background(), []string{"alice", "bob", "ghost"},
+		NullableStringMap{UserAttributeTimezone: "Europe/Berlin", UserAttributeOrganization: "R&D"})
+	if got["alice"] != nil || got["bob"] != nil {
+		t.Errorf("results: got %v, want nil for alice and bob", got)
+	}
+	if !IsNotFound(got["ghost"]) {
+		t.Errorf("ghost: got %v, want NOT_FOUND", got["ghost"])
+	}
+	for _, username := range []string{"alice", "bob"} {
+		body := written[username]
+		if _, ok := body["password"]; ok {
+			t.Errorf("%s: password sent: %s", username, body["password"])
+		}
+		var attrs map[string]string
+		if err := json.Unmarshal(body["attributes"], &attrs); err != nil {
+			t.Fatalf("%s: attributes: %v", username, err)
+		}
+		want := map[string]string{
+			UserAttributeFullName:     "Name of " + username,
+			UserAttributeTimezone:     "Europe/Berlin",
+			UserAttributeOrganization: "R&D",
+		}
+		if !reflect.DeepEqual(attrs, want) {
+			t.Errorf("%s attributes: got %v, want %v", username, attrs, want)
+		}
+	}
+}
+
+func TestRequirePasswordChange(t *testing.T) {
+	cases := []struct {
+		name    string
+		initial string
+		call    func(c *Client) error
+		want    string
+	}{
+		{"require", "", func(c *Client) error { return c.RequirePasswordChange(context.Background(), "alice") }, "true"},
+		{"clear", "true", func(c *Client) error { return c.ClearPasswordChangeRequirement(context.Background(), "alice") }, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			current := User{Username: "alice", Disabled: true, Attributes: NullableStringMap{
+				UserAttributeExpired:  tc.initial,
+				UserAttributeFullName: "Alice Liddell",
+				UserAttributeTimezone: "UTC",
+			}}
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertPath(t, r, "/api/session/data/postgresql/users/alice")
+				switch r.Method {
+				case http.MethodGet:
+					writeJSON(t, w, current)
+				case http.MethodPut:
+					body, err := io.ReadAll(r.Body)
+					if err != nil {
+						t.Fatalf("read body: %v", err)
+					}
+					if strings.Contains(string(body), `"password"`) {
+						t.Errorf("password sent: %s", body)
+					}
+					var got User
+					if err := json.Unmarshal(body, &got); err != nil {
+						t.Fatalf("decode: %v", err)
+					}
+					want := current
+					want.Attributes = NullableStringMap{
+						UserAttributeExpired:  tc.want,
+						UserAttributeFullName: "Alice Liddell",
+						UserAttributeTimezone: "UTC",
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("PUT body:\ngot  %+v\nwant %+v", got, want)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+			if err := tc.call(c); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+		})
+	}
+}
+
 // ── Permissions ───────────────────────────────────────────────────────────────
 
 func TestGetUserPermissions(t *testing.T) {
@@ -172,6 +486,39 @@ func TestGetUserGroups(t *testing.T) {
 	}
 }
 
+func TestGetUserEffectiveGroups(t *testing.T) {
+	// alice is in devs and ops; devs is in engineering, which is in staff;
+	// staff is (cyclically) in engineering.
+	parents := map[string][]string{
+		"devs":        {"engineering"},
+		"ops":         {"engineering"},
+		"engineering": {"staff"},
+		"staff":       {"engineering"},
+	}
+	fetched := map[string]int{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/session/data/postgresql/users/alice/userGroups" {
+			writeJSON(t, w, []string{"ops", "devs"})
+			return
+		}
+		group := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/session/data/postgresql/userGroups/"), "/userGroups")
+		fetched[group]++
+		writeJSON(t, w, parents[group])
+	})
+	got, err := c.GetUserEffectiveGroups(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("GetUserEffectiveGroups: %v", err)
+	}
+	if strings.Join(got, ",") != "devs,engineering,ops,staff" {
+		t.Errorf("groups: got %v, want [devs engineering ops staff]", got)
+	}
+	for group, n := range fetched {
+		if n != 1 {
+			t.Errorf("parents of %s fetched %d times, want 1", group, n)
+		}
+	}
+}
+
 func TestUpdateUserGroups(t *testing.T) {
 	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
 		assertMethod(t, r, http.MethodPatch)
@@ -191,6 +538,68 @@ func TestUpdateUserGroups(t *testing.T) {
 	}
 }
 
+func TestSetUserGroups(t *testing.T) {
+	cases := []struct {
+		name    string
+		current []string
+		desired []string
+		want    []PatchOperation
+	}{
+		{
+			"add only",
+			[]string{"devs"},
+			[]string{"ops", "devs", "admins", "ops"},
+			[]PatchOperation{AddGroupMembership("admins"), AddGroupMembership("ops")},
+		},
+		{
+			"remove only",
+			[]string{"devs", "admins", "temps"},
+			[]string{"devs"},
+			[]PatchOperation{RemoveGroupMembership("admins"), RemoveGroupMembership("temps")},
+		},
+		{
+			"no-op",
+			[]string{"devs", "admins"},
+			[]string{"admins", "devs"},
+			nil,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []PatchOperation
+			patched := false
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assertPath(t, r, "/api/session/data/postgresql/users/alice/userGroups")
+				switch r.Method {
+				case http.MethodGet:
+					writeJSON(t, w, tc.current)
+				case http.MethodPatch:
+					patched = true
+					mustReadJSON(t, r, &got)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+			if err := c.SetUserGroups(context.Background(), "alice", tc.desired); err != nil {
+				t.Fatalf("SetUserGroups: %v", err)
+			}
+			if tc.want == nil {
+				if patched {
+					t.Errorf("unexpected PATCH with ops %+v", got)
+				}
+				return
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("ops: got %+v, want %+v", got, tc.want)
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Errorf("ops[%d]: got %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
 // ── Patch helpers ─────────────────────────────────────────────────────────────
 
 func TestPatchHelpers(t *testing.T) {
@@ -239,6 +648,16 @@ func TestPatchHelpers(t *testing.T) {
 			RemoveSystemPermission(SystemPermissionAdminister),
 			PatchOperation{Op: "remove", Path: "/systemPermissions", Value: "ADMINISTER"},
 		},
+		{
+			"ReplaceOp",
+			ReplaceOp("/attributes/guac-full-name", "Alice"),
+			PatchOperation{Op: "replace", Path: "/attributes/guac-full-name", Value: "Alice"},
+		},
+		{
+			"TestOp",
+			TestOp("/attributes/guac-full-name", "Alice"),
+			PatchOperation{Op: "test", Path: "/attributes/guac-full-name", Value: "Alice"},
+		},
 		{
 			"AddGroupMembership",
 			AddGroupMembership("admins"),
@@ -258,3 +677,72 @@ func TestPatchHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestPatch_sends_replace_and_test_ops(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPatch)
+		var raw []map[string]string
+		mustReadJSON(t, r, &raw)
+		if len(raw) != 2 {
+			t.Fatalf("ops: got %d, want 2", len(raw))
+		}
+		if raw[0]["op"] != "test" || raw[1]["op"] != "replace" {
+			t.Errorf("op fields: got %q, %q; want test, replace", raw[0]["op"], raw[1]["op"])
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	err := c.UpdateUserPermissions(context.Background(), "alice", []PatchOperation{
+		TestOp("/attributes/guac-full-name", "Alice"),
+		ReplaceOp("/attributes/guac-full-name", "Alice Smith"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateUserPermissions: %v", err)
+	}
+}
+
+func TestCreateDisabledUser(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assertMethod(t, r, http.MethodPost)
+		assertPath(t, r, "/api/session/data/postgresql/users")
+		var body User
+		mustReadJSON(t, r, &body)
+		if !body.Disabled {
+			t.Error("disabled: got false, want true")
+		}
+		if got := body.Attributes[UserAttributeDisabled]; got != "true" {
+			t.Errorf("disabled attribute: got %q, want %q", got, "true")
+		}
+		if got := body.Attributes[UserAttributeFullName]; got != "Alice Liddell" {
+			t.Errorf("full name attribute: got %q, want preserved", got)
+		}
+		writeJSON(t, w, User{Username: body.Username, Disabled: body.Disabled, Attributes: body.Attributes})
+	})
+	attrs := NullableStringMap{UserAttributeFullName: "Alice Liddell"}
+	user, err := c.CreateDisabledUser(context.Background(), User{Username: "alice", Password: "s3cr3t", Attributes: attrs})
+	if err != nil {
+		t.Fatalf("CreateDisabledUser: %v", err)
+	}
+	if !user.Disabled {
+		t.Error("returned user: got enabled, want disabled")
+	}
+	if _, ok := attrs[UserAttributeDisabled]; ok {
+		t.Error("caller's attributes map was modified")
+	}
+}
+
+func TestCreateDisabledUser_nil_attributes(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var raw map[string]json.RawMessage
+		mustReadJSON(t, r, &raw)
+		if got := string(raw["attributes"]); got != `{"disabled":"true"}` {
+			t.Errorf("attributes: got %s, want {\"disabled\":\"true\"}", got)
+		}
+		if got := string(raw["disabled"]); got != "true" {
+			t.Errorf("disabled: got %s, want true", got)
+		}
+		writeJSON(t, w, User{Username: "bob", Disabled: true})
+	})
+	if _, err := c.CreateDisabledUser(context.Background(), User{Username: "bob"}); err != nil {
+		t.Fatalf("CreateDisabledUser: %v", err)
+	}
+}